@@ -0,0 +1,261 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"docker-manager/api/internal/backup"
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/crypto"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/migrations"
+	"docker-manager/api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const usage = `Usage: dockman [command]
+
+Without a command, dockman runs the API server.
+
+Commands:
+  migrate up                Apply the pending database migrations
+  migrate down [--steps n]  Revert the last n applied migrations (1 by default), add --force
+                            to revert migrations that drop tables
+  migrate status            List the migrations and whether they are applied
+  migrate legacy-secrets    Count secrets stored in the unauthenticated formats of old versions,
+                            and re-encrypt them with --confirm
+  copy-db --from <file>     Copy a SQLite database into the configured database
+  restore --from <file>     Replace the SQLite database with a backup, the server must be stopped`
+
+// runCommand runs one of the maintenance commands instead of the server.
+func runCommand(cfg *config.Config, command string, args []string) {
+	switch command {
+	case "migrate":
+		migrate(cfg, args)
+	case "copy-db":
+		copyDatabase(cfg, args)
+	case "restore":
+		restore(cfg, args)
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+	default:
+		log.Fatalf("Unknown command %q\n\n%s", command, usage)
+	}
+}
+
+// migrate applies, reverts or lists the migrations of the configured database.
+func migrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatalf("migrate: missing up, down, status or legacy-secrets\n\n%s", usage)
+	}
+	database.Init(cfg.Database, cfg.LogLevel)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(database.DB)
+		for _, migration := range applied {
+			log.Printf("Applied migration %d: %s.", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("migrate up: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Printf("The database is up to date at version %d.", migrations.Latest())
+		}
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "number of migrations to revert")
+		force := flags.Bool("force", false, "revert migrations that drop tables, deleting their data")
+		flags.Parse(args[1:])
+		if *steps < 1 {
+			log.Fatal("migrate down: --steps must be at least 1")
+		}
+		reverted, err := migrations.Down(database.DB, *steps, *force)
+		for _, migration := range reverted {
+			log.Printf("Reverted migration %d: %s.", migration.Version, migration.Name)
+		}
+		if errors.Is(err, migrations.ErrDataLoss) {
+			log.Fatalf("migrate down: %v. Nothing was reverted; back up the database and pass --force to revert anyway.", err)
+		}
+		if err != nil {
+			log.Fatalf("migrate down: %v", err)
+		}
+		if len(reverted) == 0 {
+			log.Print("No migration to revert.")
+		}
+	case "status":
+		statuses, err := migrations.List(database.DB)
+		if err != nil {
+			log.Fatalf("migrate status: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		w.Flush()
+		if err := migrations.Check(database.DB); err != nil {
+			log.Fatalf("migrate status: %v", err)
+		}
+	case "legacy-secrets":
+		flags := flag.NewFlagSet("migrate legacy-secrets", flag.ExitOnError)
+		confirm := flags.Bool("confirm", false, "re-encrypt the secrets instead of only counting them")
+		flags.Parse(args[1:])
+		migrateLegacySecrets(cfg, *confirm)
+	default:
+		log.Fatalf("migrate: unknown subcommand %q, use up, down, status or legacy-secrets", args[0])
+	}
+}
+
+// errDryRun rolls back a transaction that only counts what it would change.
+var errDryRun = errors.New("dry run")
+
+// migrateLegacySecrets re-encrypts the environment variables and registry
+// credentials still stored in the unauthenticated formats of old versions.
+// Those values may have been forged by anyone able to write to the database,
+// so DockMan refuses to read them until an administrator has checked the
+// database and run this command with --confirm. Without it, nothing changes.
+func migrateLegacySecrets(cfg *config.Config, confirm bool) {
+	initEncryption(cfg, "migrate legacy-secrets")
+	if err := migrations.Check(database.DB); err != nil {
+		log.Fatalf("migrate legacy-secrets: %v", err)
+	}
+
+	var variables, credentials int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if variables, err = models.ReencryptEnvironmentVariables(tx, crypto.IsLegacyFormat, crypto.DecryptLegacy); err != nil {
+			return err
+		}
+		if credentials, err = models.ReencryptRegistryCredentials(tx, crypto.IsLegacyFormat, crypto.DecryptLegacy); err != nil {
+			return err
+		}
+		if !confirm {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		log.Fatalf("migrate legacy-secrets: %v", err)
+	}
+	switch {
+	case variables+credentials == 0:
+		log.Print("No secret is stored in a legacy format.")
+	case !confirm:
+		log.Printf("%d environment variables and %d registry credentials are stored in an unauthenticated legacy format. "+
+			"Anyone able to write to the database could have forged them: check them, then run this command again with --confirm to re-encrypt them.", variables, credentials)
+	default:
+		log.Printf("Re-encrypted %d environment variables and %d registry credentials with authenticated encryption.", variables, credentials)
+	}
+}
+
+// initEncryption loads the configured encryption keys for a command.
+func initEncryption(cfg *config.Config, command string) {
+	keyProvider, err := crypto.NewKeyProvider(cfg.Encryption)
+	if err != nil {
+		log.Fatalf("%s: %v", command, err)
+	}
+	if err := crypto.Init(keyProvider); err != nil {
+		log.Fatalf("%s: load encryption keys: %v", command, err)
+	}
+}
+
+// copyDatabase copies a SQLite database into the configured database, to
+// move an install to PostgreSQL:
+//
+//	DOCKMAN_DB_DRIVER=postgres DOCKMAN_DB_DSN=... dockman copy-db --from dockman.db
+//
+// The configured database is migrated first. The SQLite database must not
+// have been migrated by a newer version of DockMan.
+func copyDatabase(cfg *config.Config, args []string) {
+	flags := flag.NewFlagSet("copy-db", flag.ExitOnError)
+	from := flags.String("from", "", "path of the SQLite database to copy")
+	flags.Parse(args)
+	if *from == "" {
+		log.Fatal("copy-db: --from is required")
+	}
+	if _, err := os.Stat(*from); err != nil {
+		log.Fatalf("copy-db: %v", err)
+	}
+	if cfg.Database.Driver == config.DriverSQLite && cfg.Database.DSN == *from {
+		log.Fatal("copy-db: the configured database is the one to copy, configure the target database")
+	}
+
+	src, err := database.Open(config.Database{Driver: config.DriverSQLite, DSN: *from}, &gorm.Config{})
+	if err != nil {
+		log.Fatalf("copy-db: open %s: %v", *from, err)
+	}
+	if err := migrations.Check(src); err != nil {
+		log.Fatalf("copy-db: %s: %v", *from, err)
+	}
+	database.Init(cfg.Database, cfg.LogLevel)
+	if _, err := migrations.Up(database.DB); err != nil {
+		log.Fatalf("copy-db: migrate the target database: %v", err)
+	}
+	copied, err := database.Copy(src, database.DB, schema...)
+	if err != nil {
+		log.Fatalf("copy-db: %v", err)
+	}
+	tables := make([]string, 0, len(copied))
+	for table := range copied {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		log.Printf("Copied %d rows of %s.", copied[table], table)
+	}
+}
+
+// restore replaces the SQLite database with a backup, after checking that
+// the configured encryption keys decrypt the secrets in it. Without --from,
+// it lists the backups in the backup directory.
+func restore(cfg *config.Config, args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	from := flags.String("from", "", "path of the backup to restore")
+	flags.Parse(args)
+	if cfg.Database.Driver != config.DriverSQLite {
+		log.Fatal("restore: only SQLite databases can be restored, restore PostgreSQL with pg_restore")
+	}
+	if *from == "" {
+		backups, err := backup.List(cfg.Backup.Dir)
+		if err != nil {
+			log.Fatalf("restore: %v", err)
+		}
+		if len(backups) == 0 {
+			log.Fatalf("restore: --from is required, and there is no backup in %s", cfg.Backup.Dir)
+		}
+		fmt.Printf("Backups in %s, restore one with --from:\n", cfg.Backup.Dir)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PATH\tTAKEN\tSIZE")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", b.Path, b.CreatedAt.Local().Format("2006-01-02 15:04:05"), b.Size)
+		}
+		w.Flush()
+		return
+	}
+
+	initEncryption(cfg, "restore")
+	saved, err := backup.Restore(*from, cfg.Database.DSN)
+	if saved != "" {
+		log.Printf("Saved the previous database to %s.", saved)
+	}
+	if err != nil {
+		log.Fatalf("restore: %v", err)
+	}
+	log.Printf("Restored %s to %s. Pending migrations are applied when the server starts.", *from, cfg.Database.DSN)
+}
@@ -6,35 +6,109 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
 
+	"docker-manager/api/internal/backup"
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/crypto"
 	"docker-manager/api/internal/database"
 	"docker-manager/api/internal/handlers"
+	"docker-manager/api/internal/jobs"
+	"docker-manager/api/internal/migrations"
 	"docker-manager/api/internal/models"
 	"docker-manager/api/internal/router"
 
 	"github.com/docker/docker/client"
 )
 
+// schema lists the models stored in the database, tables referenced by
+// others first.
+var schema = []interface{}{&models.Project{}, &models.Environment{}, &models.Service{}, &models.EnvironmentVariable{}, &models.User{}, &models.Session{}, &models.ProjectMember{}, &models.EnvironmentMember{}, &models.APIKey{}, &models.AuditEvent{}, &models.Deployment{}, &models.Job{}, &models.RegistryCredential{}}
+
 func main() {
-	// Initialize Docker client
+	// Load settings
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0o700); err != nil {
+		log.Fatalf("Failed to create data directory: %v", err)
+	}
+
+	if len(os.Args) > 1 {
+		runCommand(cfg, os.Args[1], os.Args[2:])
+		return
+	}
+	serve(cfg)
+}
+
+// serve runs the API server.
+func serve(cfg *config.Config) {
 	var err error
-	handlers.DockerClient, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+
+	// Initialize Docker client
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if cfg.DockerHost != "" {
+		opts = append(opts, client.WithHost(cfg.DockerHost))
+	}
+	handlers.DockerClient, err = client.NewClientWithOpts(opts...)
 	// The Docker client from client.NewClientWithOpts satisfies the DockerClientInterface
 	if err != nil {
 		log.Fatalf("Failed to create Docker client: %v", err)
 	}
 
+	// Load encryption keys
+	keyProvider, err := crypto.NewKeyProvider(cfg.Encryption)
+	if err != nil {
+		log.Fatalf("Failed to configure encryption keys: %v", err)
+	}
+	if err := crypto.Init(keyProvider); err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+	}
+
 	// Initialize Database
-	database.Init()
-	database.Migrate(&models.Project{}, &models.Environment{}, &models.Service{}, &models.EnvironmentVariable{})
+	database.Init(cfg.Database, cfg.LogLevel)
+	applied, err := migrations.Up(database.DB)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	for _, migration := range applied {
+		log.Printf("Applied migration %d: %s.", migration.Version, migration.Name)
+	}
+
+	// Jobs do not survive a restart
+	if err := jobs.Recover(); err != nil {
+		log.Fatalf("Failed to recover interrupted jobs: %v", err)
+	}
+
+	// Keep service statuses in sync with the Docker host
+	go handlers.WatchEvents(context.Background())
+
+	// Back up the database
+	handlers.BackupSettings = cfg.Backup
+	if cfg.Backup.Interval > 0 {
+		if cfg.Database.Driver == config.DriverSQLite {
+			log.Printf("Backing up the database to %s every %s.", cfg.Backup.Dir, cfg.Backup.Interval)
+			go backup.Schedule(context.Background(), database.DB, cfg.Backup)
+		} else {
+			log.Print("Scheduled backups are only supported with SQLite, back up PostgreSQL with pg_dump.")
+		}
+	}
 
 	// Setup Router
-	r := router.Setup()
+	r := router.Setup(cfg)
 
 	// Start Server
-	log.Println("Starting API server on port 8080...")
-	if err := r.Run(":8080"); err != nil {
+	if cfg.TLS.Enabled() {
+		log.Printf("Starting API server on %s (HTTPS)...", cfg.ListenAddr)
+		err = r.RunTLS(cfg.ListenAddr, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	} else {
+		log.Printf("Starting API server on %s...", cfg.ListenAddr)
+		err = r.Run(cfg.ListenAddr)
+	}
+	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
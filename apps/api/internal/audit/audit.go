@@ -0,0 +1,270 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+// Package audit records every mutating API request as an AuditEvent.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Redacted replaces the value of secret fields in recorded changes.
+const Redacted = "[REDACTED]"
+
+// maxCapture is the largest request or response body inspected, in bytes.
+const maxCapture = 64 << 10
+
+// changesKey is the gin context key handlers store explicit changes under.
+const changesKey = "audit_changes"
+
+// secretFields are the field name fragments whose values are never recorded.
+var secretFields = []string{"password", "secret", "token", "value", "credential"}
+
+// collections maps route segments to the resource type they hold.
+var collections = map[string]string{
+	"projects":     "project",
+	"environments": "environment",
+	"services":     "service",
+	"variables":    "variable",
+	"members":      "member",
+	"users":        "user",
+	"keys":         "api_key",
+	"containers":   "container",
+	"images":       "image",
+	"registries":   "registry_credential",
+	"networks":     "network",
+	"volumes":      "volume",
+}
+
+// methodVerbs names the action of requests whose route ends on a resource.
+var methodVerbs = map[string]string{
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "update",
+	http.MethodDelete: "delete",
+}
+
+// responseRecorder keeps the start of the response body so the result of
+// the request can be recorded.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if room := maxCapture - r.body.Len(); room > 0 {
+		r.body.Write(data[:min(room, len(data))])
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(data string) (int, error) {
+	return r.Write([]byte(data))
+}
+
+// replayBody reads the first bytes of a request body back before the rest
+// of it, so handlers still see the whole body.
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// Middleware writes an AuditEvent for every POST, PUT, PATCH and DELETE
+// request to a known route once it has been handled. Only the first
+// maxCapture bytes of the body are read ahead of the handler, and the
+// payload of requests made without a user is not recorded.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := methodVerbs[c.Request.Method]; !ok || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		var payload []byte
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCapture+1))
+			if err == nil {
+				payload = body
+			}
+			c.Request.Body = replayBody{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		}
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		if auth.CurrentUser(c) == nil {
+			payload = nil
+		}
+		event := newEvent(c, payload, recorder.body.Bytes())
+		if err := database.DB.Create(event).Error; err != nil {
+			log.Printf("Error writing audit event for %s %s: %v", event.Method, event.Path, err)
+		}
+	}
+}
+
+// newEvent describes a handled request.
+func newEvent(c *gin.Context, payload, response []byte) *models.AuditEvent {
+	targetType, verb, param := describeRoute(c.Request.Method, c.FullPath())
+
+	event := &models.AuditEvent{
+		ClientIP:   c.ClientIP(),
+		Action:     targetType + "." + verb,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		TargetType: targetType,
+		StatusCode: c.Writer.Status(),
+		Result:     models.AuditSuccess,
+	}
+	if user := auth.CurrentUser(c); user != nil {
+		event.ActorID = &user.ID
+		event.Actor = user.Username
+	}
+	if key := auth.CurrentAPIKey(c); key != nil {
+		event.APIKeyID = &key.ID
+	}
+
+	var body map[string]interface{}
+	if len(response) < maxCapture {
+		json.Unmarshal(response, &body)
+	}
+	if param != "" {
+		event.TargetID = c.Param(param)
+	} else if id, ok := body["ID"].(float64); ok {
+		event.TargetID = strconv.FormatUint(uint64(id), 10)
+	}
+
+	if event.StatusCode >= http.StatusBadRequest {
+		event.Result = models.AuditFailure
+		if message, ok := body["error"].(string); ok {
+			event.Error = message
+		} else if len(c.Errors) > 0 {
+			event.Error = c.Errors.String()
+		}
+	}
+
+	if scope, ok := auth.CurrentScope(c); ok && scope.ProjectID != 0 {
+		event.ProjectID = &scope.ProjectID
+	} else if targetType == "project" && event.TargetID != "" {
+		if id, err := strconv.ParseUint(event.TargetID, 10, 32); err == nil {
+			projectID := uint(id)
+			event.ProjectID = &projectID
+		}
+	}
+
+	if changes, ok := c.Get(changesKey); ok {
+		event.Changes = changes.(map[string]interface{})
+	} else if len(payload) > 0 && len(payload) <= maxCapture {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(payload, &fields); err == nil && len(fields) > 0 {
+			event.Changes = Redact(fields).(map[string]interface{})
+		}
+	}
+	return event
+}
+
+// describeRoute derives the target type and action verb of a route, and the
+// route parameter holding the target ID. For example "POST /api/services/:id/up"
+// is the action "up" on the service given by the "id" parameter, and
+// "POST /api/projects/:id/environments" creates an environment.
+func describeRoute(method, route string) (targetType, verb, param string) {
+	var verbs []string
+	for _, segment := range strings.Split(route, "/") {
+		switch {
+		case segment == "" || (segment == "api" && targetType == ""):
+		case strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*"):
+			param = segment[1:]
+			verbs = nil
+		case collections[segment] != "":
+			targetType = collections[segment]
+			param = ""
+			verbs = nil
+		case targetType == "":
+			targetType = segment
+		default:
+			verbs = append(verbs, segment)
+		}
+	}
+	if len(verbs) == 0 {
+		return targetType, methodVerbs[method], param
+	}
+	return targetType, strings.Join(verbs, "."), param
+}
+
+// isSecret reports whether a field holds a secret.
+func isSecret(field string) bool {
+	field = strings.ToLower(field)
+	for _, fragment := range secretFields {
+		if strings.Contains(field, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of a decoded JSON value with the values of secret
+// fields replaced by Redacted.
+func Redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for field, fieldValue := range v {
+			if isSecret(field) {
+				redacted[field] = Redacted
+			} else {
+				redacted[field] = Redact(fieldValue)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = Redact(item)
+		}
+		return redacted
+	}
+	return value
+}
+
+// SetChanges records the fields that differ between the before and after
+// states of a resource, instead of the request payload. Secret fields are
+// listed when they change, but their values are redacted.
+func SetChanges(c *gin.Context, before, after interface{}) {
+	from, to := fields(before), fields(after)
+	changes := map[string]interface{}{}
+	for field, value := range to {
+		if old, ok := from[field]; ok && reflect.DeepEqual(old, value) {
+			continue
+		}
+		if isSecret(field) {
+			changes[field] = map[string]interface{}{"from": Redacted, "to": Redacted}
+		} else {
+			changes[field] = map[string]interface{}{"from": Redact(from[field]), "to": Redact(value)}
+		}
+	}
+	c.Set(changesKey, changes)
+}
+
+// fields decodes the JSON representation of a value into its fields.
+func fields(value interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	if data, err := json.Marshal(value); err == nil {
+		json.Unmarshal(data, &result)
+	}
+	delete(result, "UpdatedAt")
+	return result
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeRoute(t *testing.T) {
+	tests := []struct {
+		method, route             string
+		targetType, verb, idParam string
+	}{
+		{"POST", "/api/projects", "project", "create", ""},
+		{"POST", "/api/projects/:id/environments", "environment", "create", ""},
+		{"POST", "/api/services/:id/up", "service", "up", "id"},
+		{"PUT", "/api/environments/:id/variables/:varId", "variable", "update", "varId"},
+		{"DELETE", "/containers/:id", "container", "delete", "id"},
+		{"POST", "/images/pull", "image", "pull", ""},
+		{"POST", "/api/auth/login", "auth", "login", ""},
+		{"POST", "/api/admin/encryption/rotate", "admin", "encryption.rotate", ""},
+	}
+	for _, test := range tests {
+		targetType, verb, param := describeRoute(test.method, test.route)
+		assert.Equal(t, test.targetType, targetType, test.route)
+		assert.Equal(t, test.verb, verb, test.route)
+		assert.Equal(t, test.idParam, param, test.route)
+	}
+}
+
+func TestRedactHidesSecretFields(t *testing.T) {
+	redacted := Redact(map[string]interface{}{
+		"username": "admin",
+		"password": "correct horse",
+		"variables": []interface{}{
+			map[string]interface{}{"key": "API_TOKEN", "value": "s3cret"},
+		},
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"username": "admin",
+		"password": Redacted,
+		"variables": []interface{}{
+			map[string]interface{}{"key": "API_TOKEN", "value": Redacted},
+		},
+	}, redacted)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package auth
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyPrefix starts every API key secret, so leaked keys are easy to recognise.
+const APIKeyPrefix = "dmk_"
+
+// apiKeyKey is the gin context key the authenticating API key is stored under.
+const apiKeyKey = "api_key"
+
+// lastUsedInterval limits how often the last-used time of a key is written.
+const lastUsedInterval = time.Minute
+
+// ErrInvalidAPIKey is returned for unknown, expired or revoked API keys.
+var ErrInvalidAPIKey = errors.New("invalid, expired or revoked API key")
+
+// limiter rate limits requests made with API keys.
+var limiter = NewRateLimiter()
+
+// NewAPIKeySecret returns a new API key secret and the prefix it is listed under.
+func NewAPIKeySecret() (secret, prefix string, err error) {
+	token, err := NewToken()
+	if err != nil {
+		return "", "", err
+	}
+	secret = APIKeyPrefix + token
+	return secret, secret[:len(APIKeyPrefix)+8], nil
+}
+
+// LookupAPIKey returns the active API key a secret belongs to, with its user.
+func LookupAPIKey(secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	var key models.APIKey
+	err := database.DB.Preload("User").Where("secret_hash = ?", HashToken(secret)).First(&key).Error
+	if err != nil || key.User.ID == 0 || !key.Active(time.Now()) {
+		return nil, ErrInvalidAPIKey
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey revokes a key so it can no longer be used.
+func RevokeAPIKey(key *models.APIKey) error {
+	now := time.Now()
+	if err := database.DB.Model(key).Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	limiter.Forget(key.ID)
+	return nil
+}
+
+// authenticateAPIKey authenticates a request carrying an "Authorization: Bearer"
+// header and applies the rate limit of the key. It aborts the request and
+// returns false on failure.
+func authenticateAPIKey(c *gin.Context, header string) bool {
+	secret, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must use the Bearer scheme"})
+		return false
+	}
+	key, err := LookupAPIKey(strings.TrimSpace(secret))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return false
+	}
+
+	allowed, wait := limiter.Allow(key.ID, key.RateLimit)
+	if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+		return false
+	}
+
+	// Read-only keys never change anything, whatever the route checks.
+	if !isReadOnlyMethod(c.Request.Method) && !key.MaxRole().Allows(models.RoleDeveloper) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key only has the read scope"})
+		return false
+	}
+
+	if now := time.Now(); key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedInterval {
+		if err := database.DB.Model(key).UpdateColumn("last_used_at", now).Error; err != nil {
+			log.Printf("Error recording use of API key %d: %v", key.ID, err)
+		}
+	}
+
+	c.Set(apiKeyKey, key)
+	c.Set(userKey, &key.User)
+	return true
+}
+
+// isReadOnlyMethod reports whether requests with method do not change anything.
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// CurrentAPIKey returns the API key that authenticated the request, or nil
+// for requests made with a session.
+func CurrentAPIKey(c *gin.Context) *models.APIKey {
+	if value, ok := c.Get(apiKeyKey); ok {
+		if key, ok := value.(*models.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// SessionRequired rejects requests authenticated with an API key, for
+// operations such as managing keys or passwords that need a signed-in user.
+// It must be used after Required.
+func SessionRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentAPIKey(c) != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This action cannot be performed with an API key"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// KeyScopeRequired rejects requests with API keys that are bound to a project
+// or whose scopes do not allow role. It guards routes outside any project,
+// such as creating one, and does not affect sessions. It must be used after
+// Required.
+func KeyScopeRequired(role models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := CurrentAPIKey(c); key != nil && (key.ProjectID != nil || !key.MaxRole().Allows(role)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is not allowed to perform this action"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// capRole limits a role to what the API key of the request allows. Keys bound
+// to a project have no access to other projects.
+func capRole(c *gin.Context, role models.Role, scope Scope) models.Role {
+	key := CurrentAPIKey(c)
+	if key == nil {
+		return role
+	}
+	if key.ProjectID != nil && *key.ProjectID != scope.ProjectID {
+		return ""
+	}
+	if max := key.MaxRole(); role.Allows(max) {
+		return max
+	}
+	return role
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package auth
+
+import (
+	"net/http"
+
+	"docker-manager/api/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionCookie is the name of the cookie holding the session token.
+const SessionCookie = "dockman_session"
+
+// userKey is the gin context key the authenticated user is stored under.
+const userKey = "user"
+
+// Required rejects requests that do not carry a valid session or API key.
+// The authenticated user is available to handlers through CurrentUser.
+func Required() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if header := c.GetHeader("Authorization"); header != "" {
+			if authenticateAPIKey(c, header) {
+				c.Next()
+			}
+			return
+		}
+
+		token, err := c.Cookie(SessionCookie)
+		if err != nil || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		user, err := LookupSession(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session expired, please sign in again"})
+			return
+		}
+		c.Set(userKey, user)
+		c.Next()
+	}
+}
+
+// AdminRequired rejects requests from users that are not administrators, and
+// requests with API keys lacking the admin scope or bound to a project.
+// It must be used after Required.
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user := CurrentUser(c); user == nil || !user.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Administrator access required"})
+			return
+		}
+		if key := CurrentAPIKey(c); key != nil && (key.ProjectID != nil || key.MaxRole() != models.RoleAdmin) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the admin scope"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the request comes from an administrator, signed in
+// or using an API key with the admin scope that is not bound to a project.
+func IsAdmin(c *gin.Context) bool {
+	user := CurrentUser(c)
+	if user == nil || !user.IsAdmin {
+		return false
+	}
+	key := CurrentAPIKey(c)
+	return key == nil || (key.ProjectID == nil && key.MaxRole() == models.RoleAdmin)
+}
+
+// CurrentUser returns the user authenticated by Required, or nil.
+func CurrentUser(c *gin.Context) *models.User {
+	if value, ok := c.Get(userKey); ok {
+		if user, ok := value.(*models.User); ok {
+			return user
+		}
+	}
+	return nil
+}
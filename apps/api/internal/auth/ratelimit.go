@@ -0,0 +1,74 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is the number of requests per minute allowed for API keys
+// that do not set their own limit.
+const DefaultRateLimit = 60
+
+// bucket is a token bucket holding up to one minute worth of requests.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter applies a token-bucket rate limit per key. Each key may burst up
+// to its per-minute limit, and tokens refill continuously. It is safe for
+// concurrent use.
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[uint]*bucket
+	now     func() time.Time
+}
+
+// NewRateLimiter returns an empty rate limiter.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: map[uint]*bucket{}, now: time.Now}
+}
+
+// Allow takes a token from the bucket of key id, which refills at perMinute
+// tokens per minute. When the bucket is empty it returns false and how long to
+// wait for the next token.
+func (l *RateLimiter) Allow(id uint, perMinute int) (bool, time.Duration) {
+	if perMinute <= 0 {
+		perMinute = DefaultRateLimit
+	}
+	capacity := float64(perMinute)
+	rate := capacity / time.Minute.Seconds()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[id] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Forget drops the bucket of a key, e.g. after it has been revoked.
+func (l *RateLimiter) Forget(id uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, id)
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterRefillsOverTime(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter()
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow(1, 3)
+		assert.True(t, allowed)
+	}
+	allowed, wait := limiter.Allow(1, 3)
+	assert.False(t, allowed)
+	assert.Equal(t, 20*time.Second, wait)
+
+	// Other keys have their own bucket.
+	allowed, _ = limiter.Allow(2, 3)
+	assert.True(t, allowed)
+
+	now = now.Add(20 * time.Second)
+	allowed, _ = limiter.Allow(1, 3)
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow(1, 3)
+	assert.False(t, allowed)
+}
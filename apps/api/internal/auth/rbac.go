@@ -0,0 +1,195 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ErrScopeNotFound is returned by a ScopeResolver when the resource a request
+// targets does not exist.
+var ErrScopeNotFound = errors.New("resource not found")
+
+// Scope identifies the project, and optionally the environment, a request acts on.
+type Scope struct {
+	ProjectID     uint
+	EnvironmentID uint
+}
+
+// scopeKey is the gin context key the scope checked by RequireRole is stored under.
+const scopeKey = "scope"
+
+// CurrentScope returns the scope RequireRole checked for the request, if any.
+func CurrentScope(c *gin.Context) (Scope, bool) {
+	if value, ok := c.Get(scopeKey); ok {
+		scope, ok := value.(Scope)
+		return scope, ok
+	}
+	return Scope{}, false
+}
+
+// ScopeResolver determines the scope of a request from its parameters.
+type ScopeResolver func(c *gin.Context) (Scope, error)
+
+// ProjectScope resolves the scope from a project ID route parameter.
+func ProjectScope(param string) ScopeResolver {
+	return func(c *gin.Context) (Scope, error) {
+		var project models.Project
+		if err := database.DB.Select("id").First(&project, c.Param(param)).Error; err != nil {
+			return Scope{}, notFound(err)
+		}
+		return Scope{ProjectID: project.ID}, nil
+	}
+}
+
+// EnvironmentScope resolves the scope from an environment ID route parameter.
+func EnvironmentScope(param string) ScopeResolver {
+	return func(c *gin.Context) (Scope, error) {
+		var environment models.Environment
+		if err := database.DB.Select("id", "project_id").First(&environment, c.Param(param)).Error; err != nil {
+			return Scope{}, notFound(err)
+		}
+		return Scope{ProjectID: environment.ProjectID, EnvironmentID: environment.ID}, nil
+	}
+}
+
+// ServiceScope resolves the scope from a service ID route parameter.
+func ServiceScope(param string) ScopeResolver {
+	return func(c *gin.Context) (Scope, error) {
+		var service models.Service
+		if err := database.DB.Select("id", "environment_id").First(&service, c.Param(param)).Error; err != nil {
+			return Scope{}, notFound(err)
+		}
+		return ScopeOfEnvironment(service.EnvironmentID)
+	}
+}
+
+// ProjectOnly ignores the environment of the scope returned by resolve, so
+// that only project roles are considered and environment overrides are not.
+func ProjectOnly(resolve ScopeResolver) ScopeResolver {
+	return func(c *gin.Context) (Scope, error) {
+		scope, err := resolve(c)
+		return Scope{ProjectID: scope.ProjectID}, err
+	}
+}
+
+// ScopeOfEnvironment returns the scope of an environment.
+func ScopeOfEnvironment(environmentID uint) (Scope, error) {
+	var environment models.Environment
+	if err := database.DB.Select("id", "project_id").First(&environment, environmentID).Error; err != nil {
+		return Scope{}, notFound(err)
+	}
+	return Scope{ProjectID: environment.ProjectID, EnvironmentID: environment.ID}, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrScopeNotFound
+	}
+	return err
+}
+
+// EffectiveRole returns the role a user has in a scope, or an empty role if
+// they have no access. Administrators have the admin role everywhere. An
+// environment override replaces the project role of a member, except for
+// project admins who keep full access to every environment.
+func EffectiveRole(user *models.User, scope Scope) (models.Role, error) {
+	if user.IsAdmin {
+		return models.RoleAdmin, nil
+	}
+
+	var member models.ProjectMember
+	err := database.DB.Where("project_id = ? AND user_id = ?", scope.ProjectID, user.ID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if scope.EnvironmentID == 0 || member.Role == models.RoleAdmin {
+		return member.Role, nil
+	}
+
+	var override models.EnvironmentMember
+	err = database.DB.Where("environment_id = ? AND user_id = ?", scope.EnvironmentID, user.ID).First(&override).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return member.Role, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return override.Role, nil
+}
+
+// RequireRole rejects requests from users without at least role in the scope
+// returned by resolve. It must be used after Required.
+func RequireRole(role models.Role, resolve ScopeResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+
+		scope, err := resolve(c)
+		if errors.Is(err, ErrScopeNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+
+		granted, err := EffectiveRole(user, scope)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+		granted = capRole(c, granted, scope)
+		c.Set(scopeKey, scope)
+		if !granted.Allows(role) {
+			// Hide the existence of projects the user is not a member of.
+			if granted == "" {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This action requires the " + string(role) + " role"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// VisibleProjectIDs returns the IDs of the projects the request can see: the
+// projects the user belongs to, narrowed to the project an API key is bound
+// to. It returns nil, meaning every project, for administrators.
+func VisibleProjectIDs(c *gin.Context) ([]uint, error) {
+	user := CurrentUser(c)
+	if user == nil {
+		return []uint{}, nil
+	}
+	key := CurrentAPIKey(c)
+	if key != nil && key.ProjectID != nil {
+		role, err := EffectiveRole(user, Scope{ProjectID: *key.ProjectID})
+		if err != nil || role == "" {
+			return []uint{}, err
+		}
+		return []uint{*key.ProjectID}, nil
+	}
+	if user.IsAdmin {
+		return nil, nil
+	}
+	ids := []uint{}
+	err := database.DB.Model(&models.ProjectMember{}).Where("user_id = ?", user.ID).Pluck("project_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+)
+
+// SessionLifetime is how long a session stays valid after sign-in.
+const SessionLifetime = 24 * time.Hour
+
+// ErrInvalidSession is returned for unknown or expired session tokens.
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+// NewToken returns a random URL-safe token.
+func NewToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hash a token is stored under.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSession starts a session for a user and returns its token.
+func CreateSession(user *models.User) (string, *models.Session, error) {
+	token, err := NewToken()
+	if err != nil {
+		return "", nil, err
+	}
+	session := &models.Session{
+		TokenHash: HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(SessionLifetime),
+	}
+	if err := database.DB.Create(session).Error; err != nil {
+		return "", nil, err
+	}
+	return token, session, nil
+}
+
+// LookupSession returns the user a session token belongs to.
+func LookupSession(token string) (*models.User, error) {
+	var session models.Session
+	err := database.DB.Preload("User").
+		Where("token_hash = ? AND expires_at > ?", HashToken(token), time.Now()).
+		First(&session).Error
+	if err != nil || session.User.ID == 0 {
+		return nil, ErrInvalidSession
+	}
+	return &session.User, nil
+}
+
+// DeleteSession ends the session a token belongs to.
+func DeleteSession(token string) error {
+	return database.DB.Unscoped().Where("token_hash = ?", HashToken(token)).Delete(&models.Session{}).Error
+}
+
+// DeleteExpiredSessions removes sessions that are no longer valid.
+func DeleteExpiredSessions() error {
+	return database.DB.Unscoped().Where("expires_at <= ?", time.Now()).Delete(&models.Session{}).Error
+}
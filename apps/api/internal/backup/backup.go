@@ -0,0 +1,300 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+// Package backup takes consistent snapshots of the SQLite database while the
+// server is running, and restores them once the server is stopped.
+//
+// A backup is a standalone SQLite file. It holds secrets encrypted with the
+// install's encryption keys but not the keys themselves, which must be kept
+// separately.
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/crypto"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/migrations"
+	"docker-manager/api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Backup files are named after the time they were taken, in UTC, so they
+// sort in the order they were taken.
+const (
+	filePrefix = "dockman-"
+	fileSuffix = ".db"
+	timeLayout = "20060102T150405.000Z"
+)
+
+// ErrUnsupported is returned when backing up a database other than SQLite.
+var ErrUnsupported = errors.New("only SQLite databases can be backed up, back up PostgreSQL with pg_dump")
+
+// Backup describes a backup file.
+type Backup struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// mu serializes backups, so a scheduled backup and one requested through the
+// API never write or prune at the same time.
+var mu sync.Mutex
+
+// Create writes a snapshot of db to the backup directory, then deletes the
+// oldest backups beyond the number to keep. The snapshot is taken with
+// VACUUM INTO, which reads the database in a single transaction: it is
+// consistent and does not block writers.
+func Create(db *gorm.DB, settings config.Backup) (Backup, error) {
+	if db.Dialector.Name() != "sqlite" {
+		return Backup{}, ErrUnsupported
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.MkdirAll(settings.Dir, 0o700); err != nil {
+		return Backup{}, fmt.Errorf("create backup directory: %w", err)
+	}
+	createdAt := time.Now().UTC()
+	name := filePrefix + createdAt.Format(timeLayout) + fileSuffix
+	path := filepath.Join(settings.Dir, name)
+
+	// Write to a temporary file first, so an interrupted backup is never
+	// mistaken for a complete one.
+	partial := path + ".partial"
+	if err := db.Exec("VACUUM INTO ?", partial).Error; err != nil {
+		os.Remove(partial)
+		return Backup{}, fmt.Errorf("snapshot database: %w", err)
+	}
+	if err := os.Chmod(partial, 0o600); err != nil {
+		os.Remove(partial)
+		return Backup{}, err
+	}
+	if err := os.Rename(partial, path); err != nil {
+		os.Remove(partial)
+		return Backup{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	if err := prune(settings.Dir, settings.Keep); err != nil {
+		log.Printf("Error deleting old backups: %v", err)
+	}
+	return Backup{Name: name, Path: path, Size: info.Size(), CreatedAt: createdAt}, nil
+}
+
+// List returns the backups in dir, newest first.
+func List(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, filePrefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, fileSuffix)
+		if !ok {
+			continue
+		}
+		createdAt, err := time.Parse(timeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, Backup{Name: name, Path: filepath.Join(dir, name), Size: info.Size(), CreatedAt: createdAt})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// prune deletes the backups in dir beyond the keep newest. It keeps every
+// backup when keep is 0.
+func prune(dir string, keep int) error {
+	if keep == 0 {
+		return nil
+	}
+	backups, err := List(dir)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schedule backs up db at the configured interval until ctx is canceled.
+func Schedule(ctx context.Context, db *gorm.DB, settings config.Backup) {
+	ticker := time.NewTicker(settings.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		backup, err := Create(db, settings)
+		if err != nil {
+			log.Printf("Error backing up the database: %v", err)
+			continue
+		}
+		log.Printf("Backed up the database to %s.", backup.Path)
+	}
+}
+
+// Verify checks that the backup at path can be restored: the file is an
+// intact SQLite database, it was not written by a newer version of DockMan,
+// and every secret in it can be decrypted with the loaded encryption keys.
+func Verify(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := database.Open(config.Database{Driver: config.DriverSQLite, DSN: path}, &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("%s is not a DockMan backup: %w", path, err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	var integrity string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&integrity).Error; err != nil {
+		return fmt.Errorf("%s is not a DockMan backup: %w", path, err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("%s is corrupted: %s", path, integrity)
+	}
+	if err := migrations.Check(db); err != nil {
+		return err
+	}
+	return checkKeys(db)
+}
+
+// checkKeys decrypts every secret stored in db, to make sure the loaded keys
+// are the ones the secrets were encrypted with.
+func checkKeys(db *gorm.DB) error {
+	raw := db.Session(&gorm.Session{SkipHooks: true})
+	var variables []models.EnvironmentVariable
+	if err := raw.Find(&variables).Error; err != nil {
+		return err
+	}
+	for _, variable := range variables {
+		if _, err := crypto.Decrypt(variable.Value); err != nil {
+			return fmt.Errorf("the encryption keys do not match the backup: environment variable %s cannot be decrypted with key %q: %w", variable.Key, crypto.KeyID(variable.Value), err)
+		}
+	}
+	var credentials []models.RegistryCredential
+	if err := raw.Find(&credentials).Error; err != nil {
+		return err
+	}
+	for _, credential := range credentials {
+		if _, err := crypto.Decrypt(credential.Password); err != nil {
+			return fmt.Errorf("the encryption keys do not match the backup: registry credential for %s cannot be decrypted with key %q: %w", credential.Registry, crypto.KeyID(credential.Password), err)
+		}
+	}
+	var deployments []models.Deployment
+	if err := raw.Select("id", "env_snapshot").Where("env_snapshot <> ''").Find(&deployments).Error; err != nil {
+		return err
+	}
+	for _, deployment := range deployments {
+		if _, err := deployment.Environment(); err != nil {
+			return fmt.Errorf("the encryption keys do not match the backup: the environment of deployment %d cannot be decrypted with key %q: %w", deployment.ID, crypto.KeyID(deployment.EnvSnapshot), err)
+		}
+	}
+	return nil
+}
+
+// Restore verifies the backup at path and replaces the SQLite database at
+// dsn with it. The server must be stopped. The database being replaced is
+// first saved next to it, and the path of that copy is returned.
+func Restore(path, dsn string) (string, error) {
+	if err := Verify(path); err != nil {
+		return "", err
+	}
+
+	// Save the current database, including changes still in its WAL file.
+	var saved string
+	if _, err := os.Stat(dsn); err == nil {
+		saved = dsn + ".before-restore-" + time.Now().UTC().Format(timeLayout)
+		current, err := database.Open(config.Database{Driver: config.DriverSQLite, DSN: dsn}, &gorm.Config{})
+		if err != nil {
+			return "", err
+		}
+		err = current.Exec("VACUUM INTO ?", saved).Error
+		if sqlDB, dbErr := current.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		if err != nil {
+			return "", fmt.Errorf("save the current database: %w", err)
+		}
+	}
+
+	// Copy the backup next to the database, then swap it in.
+	restoring := dsn + ".restoring"
+	if err := copyFile(path, restoring); err != nil {
+		os.Remove(restoring)
+		return saved, err
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dsn + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return saved, err
+		}
+	}
+	if err := os.Rename(restoring, dsn); err != nil {
+		return saved, err
+	}
+	return saved, nil
+}
+
+// copyFile copies the file at src to dst and flushes it to disk.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
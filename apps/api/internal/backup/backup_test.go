@@ -0,0 +1,137 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package backup
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/crypto"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/migrations"
+	"docker-manager/api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// openDatabase returns a migrated SQLite database in a file and its path.
+func openDatabase(t *testing.T) (*gorm.DB, string) {
+	dsn := filepath.Join(t.TempDir(), "dockman.db")
+	db, err := database.Open(config.Database{Driver: config.DriverSQLite, DSN: dsn}, &gorm.Config{})
+	require.NoError(t, err)
+	_, err = migrations.Up(db)
+	require.NoError(t, err)
+	return db, dsn
+}
+
+func closeDatabase(t *testing.T, db *gorm.DB) {
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+}
+
+func projectNames(t *testing.T, dsn string) []string {
+	db, err := database.Open(config.Database{Driver: config.DriverSQLite, DSN: dsn}, &gorm.Config{})
+	require.NoError(t, err)
+	defer closeDatabase(t, db)
+	var names []string
+	require.NoError(t, db.Model(&models.Project{}).Order("id").Pluck("name", &names).Error)
+	return names
+}
+
+func useKey(t *testing.T, material string) {
+	key := "primary:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat(material, 32)))
+	require.NoError(t, crypto.Init(crypto.EnvProvider{Keys: key}))
+}
+
+func TestCreateKeepsTheNewestBackups(t *testing.T) {
+	db, _ := openDatabase(t)
+	require.NoError(t, db.Create(&models.Project{Name: "shop"}).Error)
+	settings := config.Backup{Dir: filepath.Join(t.TempDir(), "backups"), Keep: 2}
+
+	var created []Backup
+	for i := 0; i < 3; i++ {
+		backup, err := Create(db, settings)
+		require.NoError(t, err)
+		created = append(created, backup)
+	}
+
+	backups, err := List(settings.Dir)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, created[2].Name, backups[0].Name)
+	assert.Equal(t, created[1].Name, backups[1].Name)
+	assert.NoFileExists(t, created[0].Path)
+	assert.Equal(t, []string{"shop"}, projectNames(t, backups[0].Path))
+	info, err := os.Stat(backups[0].Path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestRestore(t *testing.T) {
+	useKey(t, "a")
+	db, dsn := openDatabase(t)
+	require.NoError(t, db.Create(&models.Project{Name: "shop"}).Error)
+	require.NoError(t, db.Create(&models.EnvironmentVariable{Key: "TOKEN", Value: "secret", EnvironmentID: 1}).Error)
+	backup, err := Create(db, config.Backup{Dir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Project{Name: "blog"}).Error)
+	closeDatabase(t, db)
+
+	saved, err := Restore(backup.Path, dsn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shop"}, projectNames(t, dsn))
+	assert.Equal(t, []string{"shop", "blog"}, projectNames(t, saved))
+}
+
+func TestRestoreRefusesOtherEncryptionKeys(t *testing.T) {
+	useKey(t, "a")
+	db, dsn := openDatabase(t)
+	require.NoError(t, db.Create(&models.EnvironmentVariable{Key: "TOKEN", Value: "secret", EnvironmentID: 1}).Error)
+	backup, err := Create(db, config.Backup{Dir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Project{Name: "blog"}).Error)
+	closeDatabase(t, db)
+
+	// The same key ID with other key material, as on a reinstalled host.
+	useKey(t, "b")
+	saved, err := Restore(backup.Path, dsn)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `the encryption keys do not match the backup: environment variable TOKEN cannot be decrypted with key "primary"`)
+	assert.Empty(t, saved)
+	assert.Equal(t, []string{"blog"}, projectNames(t, dsn))
+}
+
+func TestVerifyChecksDeploymentSnapshots(t *testing.T) {
+	useKey(t, "a")
+	db, _ := openDatabase(t)
+	deployment := models.Deployment{ServiceID: 1, Kind: models.DeploymentUp, Status: models.DeploymentSucceeded}
+	require.NoError(t, deployment.SetEnvironment([]string{"TOKEN=secret"}, "hash"))
+	require.NoError(t, db.Create(&deployment).Error)
+	backup, err := Create(db, config.Backup{Dir: t.TempDir()})
+	require.NoError(t, err)
+	closeDatabase(t, db)
+	require.NoError(t, Verify(backup.Path))
+
+	useKey(t, "b")
+	err = Verify(backup.Path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `the environment of deployment 1 cannot be decrypted with key "primary"`)
+}
+
+func TestVerifyRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.db")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("not a database ", 100)), 0o600))
+	err := Verify(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a DockMan backup")
+}
@@ -0,0 +1,563 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package compose
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Labels set on every resource the engine creates. They are the labels used
+// by the docker compose CLI, so stacks stay recognisable by other tools.
+const (
+	ProjectLabel    = "com.docker.compose.project"
+	ServiceLabel    = "com.docker.compose.service"
+	NumberLabel     = "com.docker.compose.container-number"
+	ConfigHashLabel = "com.docker.compose.config-hash"
+	NetworkLabel    = "com.docker.compose.network"
+	VolumeLabel     = "com.docker.compose.volume"
+)
+
+// Client is the subset of the Docker API the engine relies on.
+type Client interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+
+	ImagePull(ctx context.Context, refStr string, options types.ImagePullOptions) (io.ReadCloser, error)
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+
+	NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error)
+	NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error)
+	NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error
+	NetworkRemove(ctx context.Context, networkID string) error
+
+	VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error)
+	VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error)
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+}
+
+// Project is a parsed compose file bound to the name its resources are created under.
+type Project struct {
+	// Name prefixes every resource and is stored in the ProjectLabel.
+	Name string
+	// WorkingDir is the directory relative bind mounts are resolved against.
+	WorkingDir string
+	// File is the parsed compose file. It may be nil when only tearing a project down.
+	File *File
+}
+
+// Engine deploys compose projects through the Docker API.
+type Engine struct {
+	client Client
+
+	// RegistryAuth, if set, returns the encoded login to pull an image with,
+	// or an empty string to pull it anonymously.
+	RegistryAuth func(image string) (string, error)
+	// Network, if set, is an existing network every container also joins,
+	// under its service name.
+	Network string
+	// Labels, if set, are added to every container, such as labels recording
+	// who owns the project. Containers are recreated when they change.
+	Labels map[string]string
+}
+
+// NewEngine returns an engine that talks to Docker through client.
+func NewEngine(client Client) *Engine {
+	return &Engine{client: client}
+}
+
+// Up creates the networks, volumes and containers of a project in depends_on
+// order. Containers whose configuration is unchanged are kept, others are
+// recreated. Services keep their current number of replicas, or one if new.
+func (e *Engine) Up(ctx context.Context, project *Project, out io.Writer) error {
+	order, err := project.File.StartOrder()
+	if err != nil {
+		return err
+	}
+	if err := e.createNetworks(ctx, project, out); err != nil {
+		return err
+	}
+	if err := e.createVolumes(ctx, project, out); err != nil {
+		return err
+	}
+
+	for _, name := range order {
+		existing, err := e.containers(ctx, project.Name, name)
+		if err != nil {
+			return err
+		}
+		replicas := len(existing)
+		if replicas == 0 {
+			replicas = 1
+		}
+		if err := e.converge(ctx, project, name, replicas, existing, out); err != nil {
+			return fmt.Errorf("service %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Scale runs exactly replicas containers for one service of a project.
+func (e *Engine) Scale(ctx context.Context, project *Project, service string, replicas int, out io.Writer) error {
+	if _, ok := project.File.Services[service]; !ok {
+		return fmt.Errorf("service %q is not defined in the compose file", service)
+	}
+	if replicas < 0 {
+		return fmt.Errorf("invalid number of replicas: %d", replicas)
+	}
+	if err := e.createNetworks(ctx, project, out); err != nil {
+		return err
+	}
+	if err := e.createVolumes(ctx, project, out); err != nil {
+		return err
+	}
+
+	existing, err := e.containers(ctx, project.Name, service)
+	if err != nil {
+		return err
+	}
+	if err := e.converge(ctx, project, service, replicas, existing, out); err != nil {
+		return fmt.Errorf("service %s: %w", service, err)
+	}
+	return nil
+}
+
+// Down stops and removes every container and network labelled with the
+// project name. Named volumes are only removed when removeVolumes is set.
+func (e *Engine) Down(ctx context.Context, project *Project, removeVolumes bool, out io.Writer) error {
+	existing, err := e.containers(ctx, project.Name, "")
+	if err != nil {
+		return err
+	}
+
+	// Stop dependents before the services they depend on.
+	rank := map[string]int{}
+	if project.File != nil {
+		if order, err := project.File.StartOrder(); err == nil {
+			for i, name := range order {
+				rank[name] = i
+			}
+		}
+	}
+	sort.SliceStable(existing, func(i, j int) bool {
+		return rank[existing[i].Labels[ServiceLabel]] > rank[existing[j].Labels[ServiceLabel]]
+	})
+	for _, c := range existing {
+		if err := e.removeContainer(ctx, c, out); err != nil {
+			return err
+		}
+	}
+
+	networks, err := e.client.NetworkList(ctx, types.NetworkListOptions{Filters: projectFilter(project.Name)})
+	if err != nil {
+		return err
+	}
+	for _, n := range networks {
+		if err := e.client.NetworkRemove(ctx, n.ID); err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+		fmt.Fprintf(out, "Network %s removed\n", n.Name)
+	}
+
+	if !removeVolumes {
+		return nil
+	}
+	volumes, err := e.client.VolumeList(ctx, volume.ListOptions{Filters: projectFilter(project.Name)})
+	if err != nil {
+		return err
+	}
+	for _, v := range volumes.Volumes {
+		if err := e.client.VolumeRemove(ctx, v.Name, false); err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+		fmt.Fprintf(out, "Volume %s removed\n", v.Name)
+	}
+	return nil
+}
+
+// Replicas returns the number of containers each service of a project runs.
+func (e *Engine) Replicas(ctx context.Context, projectName string) (map[string]int, error) {
+	existing, err := e.containers(ctx, projectName, "")
+	if err != nil {
+		return nil, err
+	}
+	replicas := map[string]int{}
+	for _, c := range existing {
+		replicas[c.Labels[ServiceLabel]]++
+	}
+	return replicas, nil
+}
+
+// converge makes a service run exactly replicas containers created from its current configuration.
+func (e *Engine) converge(ctx context.Context, project *Project, name string, replicas int, existing []types.Container, out io.Writer) error {
+	config, hostConfig, networks, err := containerSpec(project, name)
+	if err != nil {
+		return err
+	}
+	if e.Network != "" {
+		networks = append(networks, e.Network)
+	}
+	for key, value := range e.Labels {
+		config.Labels[key] = value
+	}
+	hash, err := configHash(config, hostConfig, networks)
+	if err != nil {
+		return err
+	}
+
+	byNumber := make(map[int]types.Container, len(existing))
+	for _, c := range existing {
+		number, _ := strconv.Atoi(c.Labels[NumberLabel])
+		if _, duplicate := byNumber[number]; number < 1 || number > replicas || duplicate {
+			if err := e.removeContainer(ctx, c, out); err != nil {
+				return err
+			}
+			continue
+		}
+		byNumber[number] = c
+	}
+
+	if replicas > 0 {
+		if err := e.ensureImage(ctx, config.Image, out); err != nil {
+			return err
+		}
+	}
+
+	for number := 1; number <= replicas; number++ {
+		if c, ok := byNumber[number]; ok {
+			if c.Labels[ConfigHashLabel] == hash {
+				if c.State != "running" {
+					if err := e.client.ContainerStart(ctx, c.ID, container.StartOptions{}); err != nil {
+						return err
+					}
+					fmt.Fprintf(out, "Container %s started\n", containerName(c))
+				} else {
+					fmt.Fprintf(out, "Container %s is up to date\n", containerName(c))
+				}
+				continue
+			}
+			// The configuration changed since the container was created.
+			if err := e.removeContainer(ctx, c, out); err != nil {
+				return err
+			}
+		}
+		if err := e.createContainer(ctx, project, name, number, hash, config, hostConfig, networks, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createContainer creates and starts one replica of a service.
+func (e *Engine) createContainer(ctx context.Context, project *Project, service string, number int, hash string, base *container.Config, hostConfig *container.HostConfig, networks []string, out io.Writer) error {
+	config := *base
+	config.Labels = make(map[string]string, len(base.Labels)+2)
+	for key, value := range base.Labels {
+		config.Labels[key] = value
+	}
+	config.Labels[NumberLabel] = strconv.Itoa(number)
+	config.Labels[ConfigHashLabel] = hash
+
+	// Containers can only be created with a single network, the others are connected afterwards.
+	aliases := []string{service}
+	networking := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{
+		networks[0]: {Aliases: aliases},
+	}}
+
+	name := fmt.Sprintf("%s-%s-%d", project.Name, service, number)
+	resp, err := e.client.ContainerCreate(ctx, &config, hostConfig, networking, nil, name)
+	if err != nil {
+		return err
+	}
+	for _, extra := range networks[1:] {
+		if err := e.client.NetworkConnect(ctx, extra, resp.ID, &network.EndpointSettings{Aliases: aliases}); err != nil {
+			return err
+		}
+	}
+	if err := e.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Container %s created and started\n", name)
+	return nil
+}
+
+// removeContainer stops and removes a container, ignoring containers that are already gone.
+func (e *Engine) removeContainer(ctx context.Context, c types.Container, out io.Writer) error {
+	timeout := 10
+	if err := e.client.ContainerStop(ctx, c.ID, container.StopOptions{Timeout: &timeout}); err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+	if err := e.client.ContainerRemove(ctx, c.ID, container.RemoveOptions{}); err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+	fmt.Fprintf(out, "Container %s removed\n", containerName(c))
+	return nil
+}
+
+// ensureImage pulls an image if it is not already present on the host.
+func (e *Engine) ensureImage(ctx context.Context, image string, out io.Writer) error {
+	if _, _, err := e.client.ImageInspectWithRaw(ctx, image); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	fmt.Fprintf(out, "Pulling image %s\n", image)
+	var options types.ImagePullOptions
+	if e.RegistryAuth != nil {
+		auth, err := e.RegistryAuth(image)
+		if err != nil {
+			return fmt.Errorf("resolve registry credentials: %w", err)
+		}
+		options.RegistryAuth = auth
+	}
+	reader, err := e.client.ImagePull(ctx, image, options)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// The pull only completes once the progress stream has been consumed.
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
+
+// createNetworks creates the project networks that do not exist yet.
+func (e *Engine) createNetworks(ctx context.Context, project *Project, out io.Writer) error {
+	existing, err := e.client.NetworkList(ctx, types.NetworkListOptions{Filters: projectFilter(project.Name)})
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(existing))
+	for _, n := range existing {
+		names[n.Name] = true
+	}
+
+	for _, key := range usedNetworks(project.File) {
+		config := project.File.Networks[key]
+		name := networkName(project, key)
+		if config.External || names[name] {
+			continue
+		}
+		driver := config.Driver
+		if driver == "" {
+			driver = "bridge"
+		}
+		labels := map[string]string{ProjectLabel: project.Name, NetworkLabel: key}
+		for label, value := range config.Labels {
+			labels[label] = value
+		}
+		if _, err := e.client.NetworkCreate(ctx, name, types.NetworkCreate{Driver: driver, Labels: labels}); err != nil {
+			return fmt.Errorf("create network %s: %w", name, err)
+		}
+		fmt.Fprintf(out, "Network %s created\n", name)
+	}
+	return nil
+}
+
+// createVolumes creates the project named volumes that do not exist yet.
+func (e *Engine) createVolumes(ctx context.Context, project *Project, out io.Writer) error {
+	existing, err := e.client.VolumeList(ctx, volume.ListOptions{Filters: projectFilter(project.Name)})
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(existing.Volumes))
+	for _, v := range existing.Volumes {
+		names[v.Name] = true
+	}
+
+	keys := make([]string, 0, len(project.File.Volumes))
+	for key := range project.File.Volumes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		config := project.File.Volumes[key]
+		name := volumeName(project, key)
+		if config.External || names[name] {
+			continue
+		}
+		labels := map[string]string{ProjectLabel: project.Name, VolumeLabel: key}
+		for label, value := range config.Labels {
+			labels[label] = value
+		}
+		if _, err := e.client.VolumeCreate(ctx, volume.CreateOptions{Name: name, Driver: config.Driver, Labels: labels}); err != nil {
+			return fmt.Errorf("create volume %s: %w", name, err)
+		}
+		fmt.Fprintf(out, "Volume %s created\n", name)
+	}
+	return nil
+}
+
+// containers lists the containers of a project, optionally restricted to one service.
+func (e *Engine) containers(ctx context.Context, projectName, service string) ([]types.Container, error) {
+	args := projectFilter(projectName)
+	if service != "" {
+		args.Add("label", ServiceLabel+"="+service)
+	}
+	return e.client.ContainerList(ctx, container.ListOptions{All: true, Filters: args})
+}
+
+// containerSpec translates a compose service into the configuration of its containers.
+// Per-replica labels are added when each container is created.
+func containerSpec(project *Project, name string) (*container.Config, *container.HostConfig, []string, error) {
+	service := project.File.Services[name]
+	if service.Image == "" {
+		return nil, nil, nil, fmt.Errorf("no image specified (building images is not supported)")
+	}
+
+	exposed, bindings, err := nat.ParsePortSpecs(service.Ports)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	labels := map[string]string{ProjectLabel: project.Name, ServiceLabel: name}
+	for _, pair := range service.Labels {
+		key, value, _ := strings.Cut(pair, "=")
+		labels[key] = value
+	}
+
+	config := &container.Config{
+		Image:        service.Image,
+		Cmd:          []string(service.Command),
+		Entrypoint:   []string(service.Entrypoint),
+		Env:          service.Environment,
+		Labels:       labels,
+		ExposedPorts: exposed,
+		User:         service.User,
+		WorkingDir:   service.WorkingDir,
+	}
+	hostConfig := &container.HostConfig{PortBindings: bindings}
+
+	if service.Restart != "" {
+		mode, retries, _ := strings.Cut(service.Restart, ":")
+		hostConfig.RestartPolicy.Name = container.RestartPolicyMode(mode)
+		hostConfig.RestartPolicy.MaximumRetryCount, _ = strconv.Atoi(retries)
+	}
+
+	for _, entry := range service.Volumes {
+		source, target, mode := splitVolume(entry)
+		switch {
+		case source == "":
+			if config.Volumes == nil {
+				config.Volumes = map[string]struct{}{}
+			}
+			config.Volumes[target] = struct{}{}
+			continue
+		case isNamedVolume(source):
+			source = volumeName(project, source)
+		default:
+			var err error
+			if source, err = bindSource(source, project.WorkingDir); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		bind := source + ":" + target
+		if mode != "" {
+			bind += ":" + mode
+		}
+		hostConfig.Binds = append(hostConfig.Binds, bind)
+	}
+
+	networks := make([]string, 0, len(service.Networks))
+	for _, key := range service.Networks {
+		networks = append(networks, networkName(project, key))
+	}
+	if len(networks) == 0 {
+		networks = append(networks, networkName(project, "default"))
+	}
+
+	return config, hostConfig, networks, nil
+}
+
+// configHash returns a digest of a container configuration, used to detect
+// containers that must be recreated.
+func configHash(config *container.Config, hostConfig *container.HostConfig, networks []string) (string, error) {
+	data, err := json.Marshal(struct {
+		Config     *container.Config
+		HostConfig *container.HostConfig
+		Networks   []string
+	}{config, hostConfig, networks})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// usedNetworks returns the sorted keys of the networks referenced by the services of a file.
+func usedNetworks(file *File) []string {
+	used := map[string]bool{}
+	for _, service := range file.Services {
+		if len(service.Networks) == 0 {
+			used["default"] = true
+		}
+		for _, key := range service.Networks {
+			used[key] = true
+		}
+	}
+	keys := make([]string, 0, len(used))
+	for key := range used {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// networkName returns the Docker name of a network declared in the compose file.
+func networkName(project *Project, key string) string {
+	config := project.File.Networks[key]
+	switch {
+	case config.Name != "":
+		return config.Name
+	case config.External:
+		return key
+	}
+	return project.Name + "_" + key
+}
+
+// volumeName returns the Docker name of a volume declared in the compose file.
+func volumeName(project *Project, key string) string {
+	config := project.File.Volumes[key]
+	switch {
+	case config.Name != "":
+		return config.Name
+	case config.External:
+		return key
+	}
+	return project.Name + "_" + key
+}
+
+// projectFilter matches the resources labelled with a project name.
+func projectFilter(projectName string) filters.Args {
+	return filters.NewArgs(filters.Arg("label", ProjectLabel+"="+projectName))
+}
+
+// containerName returns the name of a listed container without its leading slash.
+func containerName(c types.Container) string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient is an in-memory Docker daemon that keeps just enough state to
+// exercise the engine.
+type fakeClient struct {
+	containers []types.Container
+	networks   []types.NetworkResource
+	volumes    []*volume.Volume
+	created    []string
+	connected  []string
+	nextID     int
+}
+
+func matchesLabels(labels map[string]string, args filters.Args) bool {
+	for _, label := range args.Get("label") {
+		key, value, _ := strings.Cut(label, "=")
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	var result []types.Container
+	for _, c := range f.containers {
+		if matchesLabels(c.Labels, options.Filters) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.CreateResponse, error) {
+	f.nextID++
+	id := fmt.Sprintf("container-%d", f.nextID)
+	f.containers = append(f.containers, types.Container{ID: id, Names: []string{"/" + containerName}, Labels: config.Labels, State: "created"})
+	f.created = append(f.created, containerName)
+	return container.CreateResponse{ID: id}, nil
+}
+
+func (f *fakeClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	for i := range f.containers {
+		if f.containers[i].ID == containerID {
+			f.containers[i].State = "running"
+		}
+	}
+	return nil
+}
+
+func (f *fakeClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	return nil
+}
+
+func (f *fakeClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	for i, c := range f.containers {
+		if c.ID == containerID {
+			f.containers = append(f.containers[:i], f.containers[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeClient) ImagePull(ctx context.Context, refStr string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func (f *fakeClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	return types.ImageInspect{ID: imageID}, nil, nil
+}
+
+func (f *fakeClient) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	var result []types.NetworkResource
+	for _, n := range f.networks {
+		if matchesLabels(n.Labels, options.Filters) {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeClient) NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
+	f.networks = append(f.networks, types.NetworkResource{ID: name, Name: name, Labels: options.Labels})
+	return types.NetworkCreateResponse{ID: name}, nil
+}
+
+func (f *fakeClient) NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error {
+	f.connected = append(f.connected, networkID+" "+containerID+" "+strings.Join(config.Aliases, ","))
+	return nil
+}
+
+func (f *fakeClient) NetworkRemove(ctx context.Context, networkID string) error {
+	for i, n := range f.networks {
+		if n.ID == networkID {
+			f.networks = append(f.networks[:i], f.networks[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeClient) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	var result volume.ListResponse
+	for _, v := range f.volumes {
+		if matchesLabels(v.Labels, options.Filters) {
+			result.Volumes = append(result.Volumes, v)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeClient) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	v := &volume.Volume{Name: options.Name, Labels: options.Labels}
+	f.volumes = append(f.volumes, v)
+	return *v, nil
+}
+
+func (f *fakeClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	for i, v := range f.volumes {
+		if v.Name == volumeID {
+			f.volumes = append(f.volumes[:i], f.volumes[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func testProject(t *testing.T, data string) *Project {
+	file, err := Parse([]byte(data), nil)
+	require.NoError(t, err)
+	return &Project{Name: "shop", WorkingDir: "/srv/shop", File: file}
+}
+
+const testComposeFile = `
+services:
+  web:
+    image: nginx:latest
+    depends_on: [api]
+    volumes: ["./html:/usr/share/nginx/html:ro"]
+  api:
+    image: example/api:1
+    depends_on: [db]
+  db:
+    image: postgres:16
+    volumes: ["data:/var/lib/postgresql/data"]
+volumes:
+  data: {}
+`
+
+func TestEngineUpCreatesResourcesInDependencyOrder(t *testing.T) {
+	client := &fakeClient{}
+	var out strings.Builder
+
+	err := NewEngine(client).Up(context.Background(), testProject(t, testComposeFile), &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"shop-db-1", "shop-api-1", "shop-web-1"}, client.created)
+	require.Len(t, client.networks, 1)
+	assert.Equal(t, "shop_default", client.networks[0].Name)
+	assert.Equal(t, "shop", client.networks[0].Labels[ProjectLabel])
+	require.Len(t, client.volumes, 1)
+	assert.Equal(t, "shop_data", client.volumes[0].Name)
+	for _, c := range client.containers {
+		assert.Equal(t, "shop", c.Labels[ProjectLabel])
+		assert.Equal(t, "running", c.State)
+	}
+	assert.Contains(t, out.String(), "Container shop-web-1 created and started")
+
+	// A second run with the same configuration keeps the existing containers.
+	client.created = nil
+	err = NewEngine(client).Up(context.Background(), testProject(t, testComposeFile), &out)
+	require.NoError(t, err)
+	assert.Empty(t, client.created)
+
+	// Changing a service recreates only that service.
+	changed := strings.Replace(testComposeFile, "example/api:1", "example/api:2", 1)
+	err = NewEngine(client).Up(context.Background(), testProject(t, changed), &out)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shop-api-1"}, client.created)
+}
+
+func TestEngineScaleAndDown(t *testing.T) {
+	client := &fakeClient{}
+	engine := NewEngine(client)
+	project := testProject(t, testComposeFile)
+	var out strings.Builder
+
+	require.NoError(t, engine.Up(context.Background(), project, &out))
+	require.NoError(t, engine.Scale(context.Background(), project, "api", 3, &out))
+	apis, _ := client.ContainerList(context.Background(), container.ListOptions{Filters: filters.NewArgs(filters.Arg("label", ServiceLabel+"=api"))})
+	assert.Len(t, apis, 3)
+
+	require.NoError(t, engine.Scale(context.Background(), project, "api", 1, &out))
+	apis, _ = client.ContainerList(context.Background(), container.ListOptions{Filters: filters.NewArgs(filters.Arg("label", ServiceLabel+"=api"))})
+	if assert.Len(t, apis, 1) {
+		assert.Equal(t, "1", apis[0].Labels[NumberLabel])
+	}
+
+	replicas, err := engine.Replicas(context.Background(), "shop")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"web": 1, "api": 1, "db": 1}, replicas)
+
+	assert.Error(t, engine.Scale(context.Background(), project, "missing", 1, &out))
+
+	require.NoError(t, engine.Down(context.Background(), project, false, &out))
+	assert.Empty(t, client.containers)
+	assert.Empty(t, client.networks)
+	assert.Len(t, client.volumes, 1)
+}
+
+func TestEngineJoinsSharedNetwork(t *testing.T) {
+	client := &fakeClient{}
+	engine := NewEngine(client)
+	engine.Network = "dockman-env-1"
+	var out strings.Builder
+
+	require.NoError(t, engine.Up(context.Background(), testProject(t, testComposeFile), &out))
+	assert.Equal(t, []string{
+		"dockman-env-1 container-1 db",
+		"dockman-env-1 container-2 api",
+		"dockman-env-1 container-3 web",
+	}, client.connected)
+}
+
+func TestEngineLabelsContainers(t *testing.T) {
+	client := &fakeClient{}
+	engine := NewEngine(client)
+	var out strings.Builder
+	project := testProject(t, testComposeFile)
+	require.NoError(t, engine.Up(context.Background(), project, &out))
+
+	// Containers are recreated to pick up new labels.
+	engine.Labels = map[string]string{"dockman.service": "7"}
+	client.created = nil
+	require.NoError(t, engine.Up(context.Background(), project, &out))
+	assert.Len(t, client.created, 3)
+	for _, c := range client.containers {
+		assert.Equal(t, "7", c.Labels["dockman.service"])
+		assert.Equal(t, "shop", c.Labels[ProjectLabel])
+	}
+}
+
+func TestContainerSpecResolvesMounts(t *testing.T) {
+	project := testProject(t, testComposeFile)
+
+	_, hostConfig, networks, err := containerSpec(project, "web")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/srv/shop/html:/usr/share/nginx/html:ro"}, hostConfig.Binds)
+	assert.Equal(t, []string{"shop_default"}, networks)
+
+	_, hostConfig, _, err = containerSpec(project, "db")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shop_data:/var/lib/postgresql/data"}, hostConfig.Binds)
+}
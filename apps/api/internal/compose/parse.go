@@ -0,0 +1,545 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the subset of a Compose file that DockMan understands.
+type File struct {
+	Name     string                   `yaml:"name"`
+	Services map[string]ServiceConfig `yaml:"services"`
+	Networks map[string]NetworkConfig `yaml:"networks"`
+	Volumes  map[string]VolumeConfig  `yaml:"volumes"`
+}
+
+// ServiceConfig describes a single service of a Compose file.
+type ServiceConfig struct {
+	Image       string         `yaml:"image"`
+	Command     Command        `yaml:"command"`
+	Entrypoint  Command        `yaml:"entrypoint"`
+	Environment KeyValueList   `yaml:"environment"`
+	Labels      KeyValueList   `yaml:"labels"`
+	Ports       PortList       `yaml:"ports"`
+	Volumes     VolumeList     `yaml:"volumes"`
+	Networks    NetworkList    `yaml:"networks"`
+	DependsOn   DependencyList `yaml:"depends_on"`
+	Restart     string         `yaml:"restart"`
+	User        string         `yaml:"user"`
+	WorkingDir  string         `yaml:"working_dir"`
+
+	// EnvFile is only decoded so that files using env_file are rejected
+	// rather than deployed without their variables.
+	EnvFile yaml.Node `yaml:"env_file"`
+}
+
+// NetworkConfig describes a top-level network of a Compose file.
+type NetworkConfig struct {
+	Driver   string            `yaml:"driver"`
+	External bool              `yaml:"external"`
+	Name     string            `yaml:"name"`
+	Labels   map[string]string `yaml:"labels"`
+}
+
+// VolumeConfig describes a top-level named volume of a Compose file.
+type VolumeConfig struct {
+	Driver   string            `yaml:"driver"`
+	External bool              `yaml:"external"`
+	Name     string            `yaml:"name"`
+	Labels   map[string]string `yaml:"labels"`
+}
+
+// Command holds a command or entrypoint. A plain string is split into words
+// the way a POSIX shell would, honoring quotes and backslashes.
+type Command []string
+
+// KeyValueList holds "KEY=value" pairs written either as a list or as a map.
+// A key without a value, such as "- KEY" or "KEY:", is kept as "KEY".
+type KeyValueList []string
+
+// NetworkList holds the names of the networks a service is attached to,
+// written either as a list or as a map.
+type NetworkList []string
+
+// PortList holds port mappings in the short "[host_ip:]published:target[/protocol]" syntax.
+// Entries written in the long syntax are converted to the short one.
+type PortList []string
+
+// VolumeList holds mounts in the short "source:target[:mode]" syntax.
+// Entries written in the long syntax are converted to the short one.
+type VolumeList []string
+
+// DependencyList holds the names of the services a service depends on.
+// Both the list and the map ("service: {condition: ...}") syntax are accepted.
+type DependencyList []string
+
+// Load reads and parses the Compose file at path.
+// Variables referenced in the file are interpolated from env, a list of KEY=value pairs.
+func Load(path string, env []string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, env)
+}
+
+// Parse parses the contents of a Compose file and validates the service graph.
+// Variables referenced in the file are interpolated from env, a list of KEY=value pairs.
+func Parse(data []byte, env []string) (*File, error) {
+	// Variables are substituted into the parsed values rather than the raw
+	// text, so a value can never change the structure of the file.
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	var file File
+	values := variables(env)
+	if root.Kind != 0 {
+		interpolateNode(&root, values, make(map[*yaml.Node]bool))
+		if err := root.Decode(&file); err != nil {
+			return nil, err
+		}
+	}
+	if len(file.Services) == 0 {
+		return nil, errors.New("compose file defines no services")
+	}
+	for name, service := range file.Services {
+		if service.EnvFile.Kind != 0 {
+			return nil, fmt.Errorf("service %q uses env_file, which is not supported: set the variables on the environment and list them under environment instead", name)
+		}
+		service.Environment = service.Environment.passThrough(values)
+		file.Services[name] = service
+		for _, dependency := range service.DependsOn {
+			if _, ok := file.Services[dependency]; !ok {
+				return nil, fmt.Errorf("service %q depends on undefined service %q", name, dependency)
+			}
+		}
+		for _, network := range service.Networks {
+			if _, ok := file.Networks[network]; !ok && network != "default" {
+				return nil, fmt.Errorf("service %q uses undefined network %q", name, network)
+			}
+		}
+		for _, volume := range service.Volumes {
+			source, _, _ := splitVolume(volume)
+			if isNamedVolume(source) {
+				if _, ok := file.Volumes[source]; !ok {
+					return nil, fmt.Errorf("service %q uses undefined volume %q", name, source)
+				}
+			}
+		}
+	}
+	if _, err := file.StartOrder(); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// variablePattern matches $$, $VAR, ${VAR}, ${VAR:-default} and ${VAR-default}.
+var variablePattern = regexp.MustCompile(`\$(?:\$|([A-Za-z_][A-Za-z0-9_]*)|\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?-)([^}]*))?\})`)
+
+// variables returns the values of a list of KEY=value pairs by key.
+func variables(env []string) map[string]string {
+	values := make(map[string]string, len(env))
+	for _, pair := range env {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// interpolateNode substitutes variable references in the scalar values of a
+// parsed document. Mapping keys are left as written. Plain scalars are
+// resolved again after substitution, so "${PORT}" can stand for a number.
+func interpolateNode(node *yaml.Node, values map[string]string, seen map[*yaml.Node]bool) {
+	// Anchored nodes are shared by their aliases and substituted only once.
+	if seen[node] {
+		return
+	}
+	seen[node] = true
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value := interpolate(node.Value, values)
+		if value != node.Value {
+			node.Value = value
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			interpolateNode(node.Content[i], values, seen)
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			interpolateNode(child, values, seen)
+		}
+	}
+}
+
+// interpolate substitutes variable references in a value.
+// Unset variables without a default are replaced with an empty string.
+func interpolate(data string, values map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(data, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		groups := variablePattern.FindStringSubmatch(match)
+		name := groups[1] + groups[2]
+		value, set := values[name]
+		switch groups[3] {
+		case ":-":
+			if value == "" {
+				return groups[4]
+			}
+		case "-":
+			if !set {
+				return groups[4]
+			}
+		}
+		return value
+	})
+}
+
+// ServiceNames returns the names of the services in the file in alphabetical order.
+func (f *File) ServiceNames() []string {
+	names := make([]string, 0, len(f.Services))
+	for name := range f.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartOrder returns the service names ordered so that every service comes
+// after the services it depends on. Independent services are ordered alphabetically.
+func (f *File) StartOrder() ([]string, error) {
+	remaining := make(map[string]int, len(f.Services))
+	dependents := make(map[string][]string, len(f.Services))
+	for name, service := range f.Services {
+		remaining[name] = len(service.DependsOn)
+		for _, dependency := range service.DependsOn {
+			dependents[dependency] = append(dependents[dependency], name)
+		}
+	}
+
+	order := make([]string, 0, len(f.Services))
+	for len(order) < len(f.Services) {
+		var ready []string
+		for name, count := range remaining {
+			if count == 0 {
+				ready = append(ready, name)
+			}
+		}
+		if len(ready) == 0 {
+			return nil, errors.New("compose file has circular depends_on")
+		}
+		sort.Strings(ready)
+		for _, name := range ready {
+			delete(remaining, name)
+			for _, dependent := range dependents[name] {
+				remaining[dependent]--
+			}
+		}
+		order = append(order, ready...)
+	}
+	return order, nil
+}
+
+// splitVolume splits a short volume entry into its source, target and mode.
+// The source is empty for anonymous volumes.
+func splitVolume(volume string) (source, target, mode string) {
+	parts := strings.Split(volume, ":")
+	switch len(parts) {
+	case 1:
+		return "", parts[0], ""
+	case 2:
+		return parts[0], parts[1], ""
+	default:
+		return parts[0], parts[1], strings.Join(parts[2:], ":")
+	}
+}
+
+// bindSource returns the host path a bind mount source refers to, resolving
+// "~" against the home directory and relative paths against workingDir.
+func bindSource(source, workingDir string) (string, error) {
+	switch {
+	case strings.HasPrefix(source, "~"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, source[1:]), nil
+	case !filepath.IsAbs(source):
+		return filepath.Join(workingDir, source), nil
+	}
+	return source, nil
+}
+
+// BindSources returns the host paths the services of the file bind mount,
+// with relative paths resolved against workingDir.
+func (f *File) BindSources(workingDir string) ([]string, error) {
+	var sources []string
+	for _, name := range f.ServiceNames() {
+		for _, volume := range f.Services[name].Volumes {
+			source, _, _ := splitVolume(volume)
+			if source == "" || isNamedVolume(source) {
+				continue
+			}
+			path, err := bindSource(source, workingDir)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, path)
+		}
+	}
+	return sources, nil
+}
+
+// isNamedVolume reports whether a volume source refers to a named volume rather than a host path.
+func isNamedVolume(source string) bool {
+	return source != "" && !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, ".") && !strings.HasPrefix(source, "~")
+}
+
+// splitWords splits s into words like a POSIX shell, without expanding
+// anything: whitespace separates words, single quotes keep their contents
+// as is, and a backslash escapes the next character, except inside single
+// quotes. Inside double quotes, it only escapes $, `, ", \ and newline.
+func splitWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		escaped bool
+		quote   rune
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("$`\"\\\n", r) {
+				word.WriteRune('\\')
+			}
+			if r != '\n' {
+				word.WriteRune(r)
+			}
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if escaped {
+		return nil, errors.New("command ends with an unescaped backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("command has an unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (c *Command) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		args, err := splitWords(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*c = args
+		return nil
+	case yaml.SequenceNode:
+		var args []string
+		if err := node.Decode(&args); err != nil {
+			return err
+		}
+		*c = args
+		return nil
+	}
+	return fmt.Errorf("line %d: command must be a string or a list", node.Line)
+}
+
+// passThrough sets the keys listed without a value to their value in values,
+// the way Compose passes variables through from its own environment. Keys
+// without a value in values are left out.
+func (kv KeyValueList) passThrough(values map[string]string) KeyValueList {
+	var pairs KeyValueList
+	for _, pair := range kv {
+		if strings.Contains(pair, "=") {
+			pairs = append(pairs, pair)
+		} else if value, ok := values[pair]; ok {
+			pairs = append(pairs, pair+"="+value)
+		}
+	}
+	return pairs
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (kv *KeyValueList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var pairs []string
+		if err := node.Decode(&pairs); err != nil {
+			return err
+		}
+		*kv = pairs
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Tag == "!!null" {
+				*kv = append(*kv, key.Value)
+			} else {
+				*kv = append(*kv, key.Value+"="+value.Value)
+			}
+		}
+	default:
+		return fmt.Errorf("line %d: expected a list or a map", node.Line)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (n *NetworkList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		*n = names
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			*n = append(*n, node.Content[i].Value)
+		}
+	default:
+		return fmt.Errorf("line %d: networks must be a list or a map", node.Line)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (p *PortList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: ports must be a list", node.Line)
+	}
+	for _, item := range node.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			*p = append(*p, item.Value)
+		case yaml.MappingNode:
+			var long struct {
+				Target    int    `yaml:"target"`
+				Published string `yaml:"published"`
+				HostIP    string `yaml:"host_ip"`
+				Protocol  string `yaml:"protocol"`
+			}
+			if err := item.Decode(&long); err != nil {
+				return err
+			}
+			port := strconv.Itoa(long.Target)
+			if long.Published != "" {
+				port = long.Published + ":" + port
+			}
+			if long.HostIP != "" {
+				port = long.HostIP + ":" + port
+			}
+			if long.Protocol != "" {
+				port += "/" + long.Protocol
+			}
+			*p = append(*p, port)
+		default:
+			return fmt.Errorf("line %d: invalid port entry", item.Line)
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (v *VolumeList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: volumes must be a list", node.Line)
+	}
+	for _, item := range node.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			*v = append(*v, item.Value)
+		case yaml.MappingNode:
+			var long struct {
+				Source   string `yaml:"source"`
+				Target   string `yaml:"target"`
+				ReadOnly bool   `yaml:"read_only"`
+			}
+			if err := item.Decode(&long); err != nil {
+				return err
+			}
+			parts := []string{long.Target}
+			if long.Source != "" {
+				parts = []string{long.Source, long.Target}
+			}
+			if long.ReadOnly {
+				parts = append(parts, "ro")
+			}
+			*v = append(*v, strings.Join(parts, ":"))
+		default:
+			return fmt.Errorf("line %d: invalid volume entry", item.Line)
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *DependencyList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		*d = names
+	case yaml.MappingNode:
+		// Keys and values alternate in the content of a mapping node.
+		for i := 0; i < len(node.Content); i += 2 {
+			*d = append(*d, node.Content[i].Value)
+		}
+	default:
+		return fmt.Errorf("line %d: depends_on must be a list or a map", node.Line)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package compose
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	file, err := Parse([]byte(`
+services:
+  web:
+    image: nginx:latest
+    ports:
+      - "8080:80"
+      - target: 443
+        published: "8443"
+        protocol: tcp
+    volumes:
+      - ./html:/usr/share/nginx/html:ro
+      - type: volume
+        source: cache
+        target: /var/cache/nginx
+    environment:
+      TAG: ${TAG:-stable}
+    depends_on:
+      api:
+        condition: service_healthy
+  api:
+    image: example/api:${API_VERSION}
+    command: serve --port 8080
+    depends_on: [db]
+  db:
+    image: postgres:16
+volumes:
+  cache: {}
+`), []string{"API_VERSION=1.2"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"api", "db", "web"}, file.ServiceNames())
+	order, err := file.StartOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db", "api", "web"}, order)
+	web := file.Services["web"]
+	assert.Equal(t, "nginx:latest", web.Image)
+	assert.Equal(t, PortList{"8080:80", "8443:443/tcp"}, web.Ports)
+	assert.Equal(t, VolumeList{"./html:/usr/share/nginx/html:ro", "cache:/var/cache/nginx"}, web.Volumes)
+	assert.Equal(t, DependencyList{"api"}, web.DependsOn)
+	assert.Equal(t, KeyValueList{"TAG=stable"}, web.Environment)
+	assert.Equal(t, "example/api:1.2", file.Services["api"].Image)
+	assert.Equal(t, Command{"serve", "--port", "8080"}, file.Services["api"].Command)
+	assert.Equal(t, DependencyList{"db"}, file.Services["api"].DependsOn)
+}
+
+func TestParseRejectsInvalidFiles(t *testing.T) {
+	_, err := Parse([]byte("version: '3'\n"), nil)
+	assert.Error(t, err)
+
+	_, err = Parse([]byte("services:\n  web:\n    image: nginx\n    depends_on: [missing]\n"), nil)
+	assert.ErrorContains(t, err, `undefined service "missing"`)
+
+	_, err = Parse([]byte("services:\n  web:\n    image: nginx\n    volumes: [data:/data]\n"), nil)
+	assert.ErrorContains(t, err, `undefined volume "data"`)
+
+	_, err = Parse([]byte("services:\n  a:\n    depends_on: [b]\n  b:\n    depends_on: [a]\n"), nil)
+	assert.ErrorContains(t, err, "circular")
+}
+
+func TestParseInterpolatesValuesOnly(t *testing.T) {
+	file, err := Parse([]byte(`
+services:
+  web:
+    image: nginx
+    environment:
+      GREETING: ${GREETING}
+      PRICE: $$5
+    ports:
+      - target: ${PORT}
+`), []string{"GREETING=hi\n    privileged: true", "PORT=80"})
+	require.NoError(t, err)
+
+	web := file.Services["web"]
+	assert.Equal(t, KeyValueList{"GREETING=hi\n    privileged: true", "PRICE=$5"}, web.Environment)
+	assert.Equal(t, PortList{"80"}, web.Ports)
+}
+
+func TestCommandSplitsShellWords(t *testing.T) {
+	for input, want := range map[string]Command{
+		`sh -c "echo hi"`:            {"sh", "-c", "echo hi"},
+		`echo 'a  b' c\ d`:           {"echo", "a  b", "c d"},
+		`printf "%s\n" "say \"hi\""`: {"printf", `%s\n`, `say "hi"`},
+		`run --name=""`:              {"run", "--name="},
+		`  serve   --port 8080  `:    {"serve", "--port", "8080"},
+	} {
+		file, err := Parse([]byte("services:\n  web:\n    image: nginx\n    command: "+quoteYAML(input)+"\n"), nil)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, file.Services["web"].Command, input)
+	}
+
+	_, err := Parse([]byte("services:\n  web:\n    image: nginx\n    command: "+quoteYAML(`sh -c "echo`)+"\n"), nil)
+	assert.ErrorContains(t, err, "unterminated")
+}
+
+// quoteYAML writes s as a single-quoted YAML scalar.
+func quoteYAML(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func TestParsePassesEnvironmentVariablesThrough(t *testing.T) {
+	file, err := Parse([]byte(`
+services:
+  web:
+    image: nginx
+    environment:
+      - DATABASE_URL
+      - MISSING
+      - MODE=production
+  worker:
+    image: worker
+    environment:
+      DATABASE_URL:
+      EMPTY: ${UNSET}
+`), []string{"DATABASE_URL=postgres://db/app"})
+	require.NoError(t, err)
+
+	assert.Equal(t, KeyValueList{"DATABASE_URL=postgres://db/app", "MODE=production"}, file.Services["web"].Environment)
+	assert.Equal(t, KeyValueList{"DATABASE_URL=postgres://db/app", "EMPTY="}, file.Services["worker"].Environment)
+}
+
+func TestParseRejectsEnvFile(t *testing.T) {
+	_, err := Parse([]byte("services:\n  web:\n    image: nginx\n    env_file: .env\n"), nil)
+	assert.ErrorContains(t, err, `service "web" uses env_file`)
+}
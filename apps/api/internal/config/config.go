@@ -3,8 +3,331 @@
 // Company: Bouali Consulting Inc.
 // GitHub: https://github.com/kaissb
 
+// Package config loads the settings of the API server from an optional YAML
+// file and from DOCKMAN_* environment variables, which take precedence.
 package config
 
-// TODO: Load this from a secure environment variable or a secrets manager in production.
-// IMPORTANT: This key must be exactly 32 bytes long for AES-256.
-var EncryptionKey = []byte("7k9mP2xQ8vR5nL3wJ6fT1yU4hG0sA2zB")
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LegacyEncryptionKey is the key every DockMan install shared before keys became
+// configurable. It is only used to decrypt values written with it, so they can
+// be rotated to the install's own key. It must never become the primary key again.
+var LegacyEncryptionKey = []byte("7k9mP2xQ8vR5nL3wJ6fT1yU4hG0sA2zB")
+
+// Log levels, from the most to the least verbose.
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+// Database drivers.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Config holds the settings of the API server.
+type Config struct {
+	// ListenAddr is the host:port the server listens on.
+	ListenAddr string `yaml:"listen_addr"`
+	// TLS serves HTTPS when both a certificate and a key are set.
+	TLS TLS `yaml:"tls"`
+	// AllowedOrigins are the origins of the web UI allowed to call the API
+	// from a browser.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	Database       Database `yaml:"database"`
+	// DockerHost is the address of the Docker daemon, such as
+	// unix:///var/run/docker.sock. Docker's own DOCKER_HOST is used if empty.
+	DockerHost string `yaml:"docker_host"`
+	// AllowedBindPaths are the host directories users other than
+	// administrators may bind mount into containers, with everything under
+	// them. Only administrators may bind mount other host paths.
+	AllowedBindPaths []string `yaml:"allowed_bind_paths"`
+	// DataDir is the directory DockMan keeps its state in: the SQLite
+	// database and the local keystore unless configured otherwise.
+	DataDir    string     `yaml:"data_dir"`
+	LogLevel   string     `yaml:"log_level"`
+	Encryption Encryption `yaml:"encryption"`
+	Backup     Backup     `yaml:"backup"`
+}
+
+// TLS holds the certificate the server serves HTTPS with.
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// Enabled reports whether HTTPS is configured.
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Database selects the database DockMan stores its data in. Several API
+// servers can share a PostgreSQL database.
+type Database struct {
+	// Driver is "sqlite" or "postgres".
+	Driver string `yaml:"driver"`
+	// DSN is the data source name: a file path for SQLite, defaulting to
+	// dockman.db in the data directory, or a connection string for PostgreSQL,
+	// such as "host=db user=dockman dbname=dockman" or a postgres:// URL.
+	DSN string `yaml:"dsn"`
+}
+
+// Encryption selects where the keys encrypting secrets are loaded from.
+type Encryption struct {
+	// Provider is "env", "file" or "local". When empty, "env" is used if Keys
+	// is set, "file" if KeyFile is set and "local" otherwise.
+	Provider string `yaml:"provider"`
+	// Keys holds comma separated "id:base64-key" pairs for the "env" provider.
+	// The first key is the primary key.
+	Keys string `yaml:"keys"`
+	// KeyFile is the path of a file with one "id:base64-key" pair per line for
+	// the "file" provider. The first key is the primary key.
+	KeyFile string `yaml:"key_file"`
+	// KeystoreDir is the directory the "local" provider keeps its keys in.
+	// Defaults to keys in the data directory.
+	KeystoreDir string `yaml:"keystore_dir"`
+}
+
+// Backup schedules snapshots of the SQLite database. PostgreSQL databases
+// are backed up with pg_dump instead.
+type Backup struct {
+	// Dir is the directory backups are written to. Defaults to backups in
+	// the data directory.
+	Dir string `yaml:"dir"`
+	// Interval is the time between scheduled backups, such as 6h. 0 disables
+	// scheduled backups.
+	Interval time.Duration `yaml:"interval"`
+	// Keep is the number of backups kept, older ones are deleted. 0 keeps
+	// every backup.
+	Keep int `yaml:"keep"`
+}
+
+// Default returns the settings used when nothing is configured.
+func Default() *Config {
+	return &Config{
+		ListenAddr:     ":8080",
+		AllowedOrigins: []string{"http://localhost:5173"},
+		Database:       Database{Driver: DriverSQLite},
+		DataDir:        ".",
+		LogLevel:       LogInfo,
+		Backup:         Backup{Interval: 24 * time.Hour, Keep: 7},
+	}
+}
+
+// Load reads the settings from the YAML file named by DOCKMAN_CONFIG, if
+// set, then from the environment, and validates them.
+func Load() (*Config, error) {
+	cfg := Default()
+	if path := os.Getenv("DOCKMAN_CONFIG"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	cfg.setDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile reads settings from a YAML file. Unknown keys are rejected so a
+// misspelled setting does not go unnoticed.
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	defer f.Close()
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv overrides settings with the environment variables that are set.
+func (c *Config) loadEnv(lookup func(string) (string, bool)) error {
+	settings := map[string]*string{
+		"DOCKMAN_LISTEN_ADDR":         &c.ListenAddr,
+		"DOCKMAN_TLS_CERT_FILE":       &c.TLS.CertFile,
+		"DOCKMAN_TLS_KEY_FILE":        &c.TLS.KeyFile,
+		"DOCKMAN_DB_DRIVER":           &c.Database.Driver,
+		"DOCKMAN_DB_DSN":              &c.Database.DSN,
+		"DOCKMAN_DOCKER_HOST":         &c.DockerHost,
+		"DOCKMAN_DATA_DIR":            &c.DataDir,
+		"DOCKMAN_LOG_LEVEL":           &c.LogLevel,
+		"DOCKMAN_KEY_PROVIDER":        &c.Encryption.Provider,
+		"DOCKMAN_ENCRYPTION_KEYS":     &c.Encryption.Keys,
+		"DOCKMAN_ENCRYPTION_KEY_FILE": &c.Encryption.KeyFile,
+		"DOCKMAN_KEYSTORE_DIR":        &c.Encryption.KeystoreDir,
+		"DOCKMAN_BACKUP_DIR":          &c.Backup.Dir,
+	}
+	for name, setting := range settings {
+		if value, ok := lookup(name); ok {
+			*setting = value
+		}
+	}
+	if value, ok := lookup("DOCKMAN_ALLOWED_ORIGINS"); ok {
+		c.AllowedOrigins = splitList(value)
+	}
+	if value, ok := lookup("DOCKMAN_ALLOWED_BIND_PATHS"); ok {
+		c.AllowedBindPaths = splitList(value)
+	}
+	if value, ok := lookup("DOCKMAN_BACKUP_INTERVAL"); ok {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("DOCKMAN_BACKUP_INTERVAL: %q is not a duration, such as 24h", value)
+		}
+		c.Backup.Interval = interval
+	}
+	if value, ok := lookup("DOCKMAN_BACKUP_KEEP"); ok {
+		keep, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("DOCKMAN_BACKUP_KEEP: %q is not a number", value)
+		}
+		c.Backup.Keep = keep
+	}
+	return nil
+}
+
+// splitList splits a comma separated environment variable, ignoring blanks.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// setDefaults fills in the settings that default to a path in the data
+// directory.
+func (c *Config) setDefaults() {
+	if c.Database.Driver == DriverSQLite && c.Database.DSN == "" {
+		c.Database.DSN = filepath.Join(c.DataDir, "dockman.db")
+	}
+	if c.Encryption.KeystoreDir == "" {
+		c.Encryption.KeystoreDir = filepath.Join(c.DataDir, "keys")
+	}
+	if c.Backup.Dir == "" {
+		c.Backup.Dir = filepath.Join(c.DataDir, "backups")
+	}
+}
+
+// Validate checks the settings, reporting every invalid one by its name in
+// the config file, separated by semicolons.
+func (c *Config) Validate() error {
+	var problems []string
+	fail := func(field, format string, args ...interface{}) {
+		problems = append(problems, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	if _, port, err := net.SplitHostPort(c.ListenAddr); err != nil {
+		fail("listen_addr", "must be host:port, such as :8080 or 127.0.0.1:8080")
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		fail("listen_addr", "%q does not end with a port between 0 and 65535", c.ListenAddr)
+	}
+
+	if c.TLS.Enabled() {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			fail("tls", "cert_file and key_file must be set together")
+		} else if _, err := tls.LoadX509KeyPair(c.TLS.CertFile, c.TLS.KeyFile); err != nil {
+			fail("tls", "cannot load the certificate: %v", err)
+		}
+	}
+
+	if len(c.AllowedOrigins) == 0 {
+		fail("allowed_origins", "at least one origin is required")
+	}
+	for _, origin := range c.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			fail("allowed_origins", "%q must be a scheme and host, such as https://dockman.example.com", origin)
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverSQLite, DriverPostgres:
+		if c.Database.DSN == "" {
+			fail("database.dsn", "is required")
+		}
+	default:
+		fail("database.driver", "%q is not supported, use %s or %s", c.Database.Driver, DriverSQLite, DriverPostgres)
+	}
+
+	if c.DockerHost != "" {
+		u, err := url.Parse(c.DockerHost)
+		switch {
+		case err != nil:
+			fail("docker_host", "%q is not a valid address", c.DockerHost)
+		case u.Scheme != "unix" && u.Scheme != "npipe" && u.Scheme != "tcp" && u.Scheme != "http" && u.Scheme != "https":
+			fail("docker_host", "must start with unix://, npipe://, tcp://, http:// or https://")
+		}
+	}
+
+	for _, dir := range c.AllowedBindPaths {
+		if !filepath.IsAbs(dir) {
+			fail("allowed_bind_paths", "%q must be an absolute path", dir)
+		}
+	}
+
+	if c.DataDir == "" {
+		fail("data_dir", "is required")
+	} else if info, err := os.Stat(c.DataDir); err == nil && !info.IsDir() {
+		fail("data_dir", "%s is not a directory", c.DataDir)
+	}
+
+	switch c.LogLevel {
+	case LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		fail("log_level", "must be %s, %s, %s or %s", LogDebug, LogInfo, LogWarn, LogError)
+	}
+
+	switch c.Encryption.Provider {
+	case "", "local":
+	case "env":
+		if c.Encryption.Keys == "" {
+			fail("encryption.keys", "is required by the env provider")
+		}
+	case "file":
+		if c.Encryption.KeyFile == "" {
+			fail("encryption.key_file", "is required by the file provider")
+		}
+	default:
+		fail("encryption.provider", "must be env, file or local")
+	}
+
+	if c.Backup.Interval != 0 && c.Backup.Interval < time.Minute {
+		fail("backup.interval", "must be 0 to disable scheduled backups or at least 1m")
+	}
+	if c.Backup.Keep < 0 {
+		fail("backup.keep", "must be 0 to keep every backup or more")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
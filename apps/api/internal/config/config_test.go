@@ -0,0 +1,111 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "dockman.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("DOCKMAN_CONFIG", "")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", cfg.ListenAddr)
+	assert.Equal(t, []string{"http://localhost:5173"}, cfg.AllowedOrigins)
+	assert.Equal(t, Database{Driver: DriverSQLite, DSN: "dockman.db"}, cfg.Database)
+	assert.Equal(t, "keys", cfg.Encryption.KeystoreDir)
+	assert.Equal(t, Backup{Dir: "backups", Interval: 24 * time.Hour, Keep: 7}, cfg.Backup)
+	assert.False(t, cfg.TLS.Enabled())
+}
+
+func TestLoadFileThenEnvironment(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("DOCKMAN_CONFIG", writeConfig(t, `
+listen_addr: 127.0.0.1:9000
+allowed_origins: [https://dockman.example.com]
+docker_host: tcp://docker:2375
+data_dir: `+dataDir+`
+log_level: debug
+encryption:
+  provider: file
+  key_file: /etc/dockman/keys
+backup:
+  interval: 6h
+  keep: 30
+`))
+	t.Setenv("DOCKMAN_LOG_LEVEL", "warn")
+	t.Setenv("DOCKMAN_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com")
+	t.Setenv("DOCKMAN_BACKUP_KEEP", "14")
+	t.Setenv("DOCKMAN_ALLOWED_BIND_PATHS", "/srv/dockman,/var/www")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:9000", cfg.ListenAddr)
+	assert.Equal(t, "tcp://docker:2375", cfg.DockerHost)
+	assert.Equal(t, LogWarn, cfg.LogLevel)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.AllowedOrigins)
+	assert.Equal(t, []string{"/srv/dockman", "/var/www"}, cfg.AllowedBindPaths)
+	assert.Equal(t, filepath.Join(dataDir, "dockman.db"), cfg.Database.DSN)
+	assert.Equal(t, filepath.Join(dataDir, "keys"), cfg.Encryption.KeystoreDir)
+	assert.Equal(t, Encryption{Provider: "file", KeyFile: "/etc/dockman/keys", KeystoreDir: filepath.Join(dataDir, "keys")}, cfg.Encryption)
+	assert.Equal(t, Backup{Dir: filepath.Join(dataDir, "backups"), Interval: 6 * time.Hour, Keep: 14}, cfg.Backup)
+}
+
+func TestLoadRejectsInvalidEnvironment(t *testing.T) {
+	t.Setenv("DOCKMAN_CONFIG", "")
+	t.Setenv("DOCKMAN_BACKUP_INTERVAL", "daily")
+	_, err := Load()
+	assert.EqualError(t, err, `DOCKMAN_BACKUP_INTERVAL: "daily" is not a duration, such as 24h`)
+}
+
+func TestLoadRejectsUnknownSettings(t *testing.T) {
+	t.Setenv("DOCKMAN_CONFIG", writeConfig(t, "listen_address: :9000\n"))
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field listen_address not found")
+}
+
+func TestValidateReportsEveryInvalidSetting(t *testing.T) {
+	file := writeConfig(t, "")
+	cfg := &Config{
+		ListenAddr:       "8080",
+		TLS:              TLS{CertFile: "/etc/dockman/cert.pem"},
+		AllowedOrigins:   []string{"localhost:5173"},
+		Database:         Database{Driver: "mysql"},
+		DockerHost:       "docker:2375",
+		AllowedBindPaths: []string{"srv/data"},
+		DataDir:          file,
+		LogLevel:         "verbose",
+		Encryption:       Encryption{Provider: "env"},
+		Backup:           Backup{Interval: time.Second, Keep: -1},
+	}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Equal(t, "invalid configuration: "+
+		"listen_addr: must be host:port, such as :8080 or 127.0.0.1:8080; "+
+		"tls: cert_file and key_file must be set together; "+
+		`allowed_origins: "localhost:5173" must be a scheme and host, such as https://dockman.example.com; `+
+		`database.driver: "mysql" is not supported, use sqlite or postgres; `+
+		"docker_host: must start with unix://, npipe://, tcp://, http:// or https://; "+
+		`allowed_bind_paths: "srv/data" must be an absolute path; `+
+		"data_dir: "+file+" is not a directory; "+
+		"log_level: must be debug, info, warn or error; "+
+		"encryption.keys: is required by the env provider; "+
+		"backup.interval: must be 0 to disable scheduled backups or at least 1m; "+
+		"backup.keep: must be 0 to keep every backup or more", err.Error())
+}
@@ -8,38 +8,151 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
-	"docker-manager/api/internal/config"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
 )
 
-// Encrypt encrypts text using AES-256-CFB.
+// Ciphertexts are written as "<version>:<key ID>:<base64 data>":
+//   - v2 is AES-256-GCM, with the key ID as additional authenticated data.
+//   - v1 is unauthenticated AES-256-CFB.
+//
+// Values written before key IDs existed are bare base64 AES-256-CFB data
+// encrypted with the legacy key. Neither v1 nor bare values can be trusted:
+// they are not authenticated and the legacy key is public, so anyone able to
+// write to the database can forge them. Decrypt rejects them; only the
+// one-time migration of an old install reads them, with DecryptLegacy.
+const (
+	formatV1 = "v1"
+	formatV2 = "v2"
+)
+
+// ErrAuthentication is returned when a ciphertext was tampered with or
+// encrypted with a different key than the one registered under its key ID.
+var ErrAuthentication = errors.New("ciphertext failed authentication")
+
+// ErrLegacyFormat is returned when decrypting a value stored in one of the
+// unauthenticated formats of older versions.
+var ErrLegacyFormat = errors.New(`ciphertext uses an unauthenticated legacy format, upgrade it with "dockman migrate legacy-secrets"`)
+
+// Encrypt encrypts text using AES-256-GCM with the primary key.
 func Encrypt(text string) (string, error) {
-	block, err := aes.NewCipher(config.EncryptionKey)
+	keyID, key, err := keyring.primaryKey()
 	if err != nil {
 		return "", err
 	}
-	plaintext := []byte(text)
-	ciphertext := make([]byte, aes.BlockSize+len(plaintext))
-	iv := ciphertext[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(text)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", err
 	}
-	stream := cipher.NewCFBEncrypter(block, iv)
-	stream.XORKeyStream(ciphertext[aes.BlockSize:], plaintext)
-	return base64.URLEncoding.EncodeToString(ciphertext), nil
+	ciphertext := aead.Seal(nonce, nonce, []byte(text), []byte(keyID))
+	return formatV2 + ":" + keyID + ":" + base64.URLEncoding.EncodeToString(ciphertext), nil
 }
 
-// Decrypt decrypts text using AES-256-CFB.
+// Decrypt decrypts text with the key it was encrypted with.
+// Only the authenticated v2 format is accepted.
 func Decrypt(cryptoText string) (string, error) {
-	ciphertext, err := base64.URLEncoding.DecodeString(cryptoText)
+	version, keyID, data := splitCiphertext(cryptoText)
+	if version != formatV2 {
+		return "", ErrLegacyFormat
+	}
+	ciphertext, err := base64.URLEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	key, err := keyring.key(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("%w (key %q)", ErrAuthentication, keyID)
+	}
+	return string(plaintext), nil
+}
+
+// DecryptLegacy decrypts text in any format, including the unauthenticated
+// ones. It must only be used to migrate the values of an old install, once
+// an administrator has chosen to trust them.
+func DecryptLegacy(cryptoText string) (string, error) {
+	version, keyID, data := splitCiphertext(cryptoText)
+	if version == formatV2 {
+		return Decrypt(cryptoText)
+	}
+	ciphertext, err := base64.URLEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	key, err := keyring.key(keyID)
+	if err != nil {
+		return "", err
+	}
+	return decryptCFB(key, ciphertext)
+}
+
+// digestContext separates the keys digests are computed with from the keys
+// values are encrypted with.
+const digestContext = "dockman digest"
+
+// Digest returns an HMAC-SHA256 of data keyed with the primary key, written
+// as "<key ID>:<hex digest>". Unlike a plain hash, it reveals nothing about
+// secrets in data to whoever can read it.
+func Digest(data []byte) (string, error) {
+	keyID, key, err := keyring.primaryKey()
 	if err != nil {
 		return "", err
 	}
+	return keyID + ":" + hex.EncodeToString(digest(key, data)), nil
+}
+
+// MatchesDigest reports whether a digest returned by Digest was computed
+// from data, with the key it names, so digests stay valid after the primary
+// key changes.
+func MatchesDigest(data []byte, sum string) bool {
+	keyID, encoded, ok := strings.Cut(sum, ":")
+	if !ok || keyID == LegacyKeyID {
+		return false
+	}
+	key, err := keyring.key(keyID)
+	if err != nil {
+		return false
+	}
+	expected, err := hex.DecodeString(encoded)
+	return err == nil && hmac.Equal(expected, digest(key, data))
+}
+
+// digest computes the HMAC-SHA256 of data with a key derived from an
+// encryption key.
+func digest(key, data []byte) []byte {
+	derive := hmac.New(sha256.New, key)
+	derive.Write([]byte(digestContext))
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
 
-	block, err := aes.NewCipher(config.EncryptionKey)
+// decryptCFB decrypts the AES-256-CFB data of the v1 and legacy formats.
+func decryptCFB(key, ciphertext []byte) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -55,3 +168,42 @@ func Decrypt(cryptoText string) (string, error) {
 
 	return string(ciphertext), nil
 }
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyID returns the ID of the key a ciphertext was encrypted with.
+func KeyID(cryptoText string) string {
+	_, keyID, _ := splitCiphertext(cryptoText)
+	return keyID
+}
+
+// IsLegacyFormat reports whether a ciphertext uses an unauthenticated format.
+func IsLegacyFormat(cryptoText string) bool {
+	version, _, _ := splitCiphertext(cryptoText)
+	return version != formatV2
+}
+
+// NeedsReencryption reports whether a ciphertext uses a legacy format or was
+// encrypted with a key other than the primary key.
+func NeedsReencryption(cryptoText string) bool {
+	return IsLegacyFormat(cryptoText) || KeyID(cryptoText) != keyring.Primary()
+}
+
+// splitCiphertext returns the format version, the key ID and the base64 data
+// of a ciphertext. The version is empty for bare legacy values.
+func splitCiphertext(cryptoText string) (version, keyID, data string) {
+	for _, v := range []string{formatV2, formatV1} {
+		if rest, ok := strings.CutPrefix(cryptoText, v+":"); ok {
+			if keyID, data, ok := strings.Cut(rest, ":"); ok {
+				return v, keyID, data
+			}
+		}
+	}
+	return "", LegacyKeyID, cryptoText
+}
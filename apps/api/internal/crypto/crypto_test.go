@@ -0,0 +1,177 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useKeys replaces the package keyring for the duration of a test.
+func useKeys(t *testing.T, p KeyProvider) {
+	previousKeyring, previousProvider := keyring, provider
+	t.Cleanup(func() { keyring, provider = previousKeyring, previousProvider })
+	keyring = NewKeyring()
+	require.NoError(t, Init(p))
+}
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestEncryptUsesPrimaryKey(t *testing.T) {
+	useKeys(t, EnvProvider{Keys: "new:" + testKey('n') + ",old:" + testKey('o')})
+
+	ciphertext, err := Encrypt("s3cret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ciphertext, "v2:new:"))
+	assert.Equal(t, "new", KeyID(ciphertext))
+	assert.False(t, NeedsReencryption(ciphertext))
+
+	plaintext, err := Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", plaintext)
+}
+
+func TestDecryptLegacyCiphertext(t *testing.T) {
+	useKeys(t, EnvProvider{Keys: "new:" + testKey('n')})
+
+	// Values written before key IDs existed are bare base64 encrypted with the legacy key.
+	block, _ := aes.NewCipher(config.LegacyEncryptionKey)
+	data := make([]byte, aes.BlockSize+len("legacy value"))
+	cipher.NewCFBEncrypter(block, data[:aes.BlockSize]).XORKeyStream(data[aes.BlockSize:], []byte("legacy value"))
+	legacy := base64.URLEncoding.EncodeToString(data)
+
+	assert.Equal(t, LegacyKeyID, KeyID(legacy))
+	assert.True(t, IsLegacyFormat(legacy))
+	assert.True(t, NeedsReencryption(legacy))
+	_, err := Decrypt(legacy)
+	assert.ErrorIs(t, err, ErrLegacyFormat)
+	plaintext, err := DecryptLegacy(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy value", plaintext)
+}
+
+func TestDecryptV1Ciphertext(t *testing.T) {
+	useKeys(t, EnvProvider{Keys: "new:" + testKey('n')})
+
+	block, _ := aes.NewCipher([]byte(strings.Repeat("n", 32)))
+	data := make([]byte, aes.BlockSize+len("v1 value"))
+	cipher.NewCFBEncrypter(block, data[:aes.BlockSize]).XORKeyStream(data[aes.BlockSize:], []byte("v1 value"))
+	v1 := "v1:new:" + base64.URLEncoding.EncodeToString(data)
+
+	assert.True(t, IsLegacyFormat(v1))
+	assert.True(t, NeedsReencryption(v1))
+	_, err := Decrypt(v1)
+	assert.ErrorIs(t, err, ErrLegacyFormat)
+	plaintext, err := DecryptLegacy(v1)
+	require.NoError(t, err)
+	assert.Equal(t, "v1 value", plaintext)
+}
+
+func TestDecryptDetectsTampering(t *testing.T) {
+	useKeys(t, EnvProvider{Keys: "a:" + testKey('a') + ",b:" + testKey('b')})
+	ciphertext, err := Encrypt("value")
+	require.NoError(t, err)
+
+	data, _ := base64.URLEncoding.DecodeString(strings.TrimPrefix(ciphertext, "v2:a:"))
+	data[len(data)-1] ^= 1
+	_, err = Decrypt("v2:a:" + base64.URLEncoding.EncodeToString(data))
+	assert.ErrorIs(t, err, ErrAuthentication)
+
+	// Relabelling a ciphertext with another key ID is detected too.
+	_, err = Decrypt(strings.Replace(ciphertext, "v2:a:", "v2:b:", 1))
+	assert.ErrorIs(t, err, ErrAuthentication)
+}
+
+func TestDecryptWithUnknownKeyFails(t *testing.T) {
+	useKeys(t, EnvProvider{Keys: "a:" + testKey('a')})
+	ciphertext, err := Encrypt("value")
+	require.NoError(t, err)
+
+	useKeys(t, EnvProvider{Keys: "b:" + testKey('b')})
+	_, err = Decrypt(ciphertext)
+	assert.ErrorContains(t, err, `unknown encryption key "a"`)
+}
+
+func TestLocalKMSProviderGeneratesAndRotatesKeys(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	useKeys(t, &LocalKMSProvider{Dir: dir})
+
+	first := Keys().Primary()
+	require.NotEmpty(t, first)
+	assert.FileExists(t, filepath.Join(dir, first+".key"))
+	ciphertext, err := Encrypt("value")
+	require.NoError(t, err)
+
+	second, err := GenerateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.True(t, NeedsReencryption(ciphertext))
+
+	// A fresh process picks up both keys and the new primary key.
+	useKeys(t, &LocalKMSProvider{Dir: dir})
+	assert.Equal(t, second, Keys().Primary())
+	plaintext, err := Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "value", plaintext)
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# primary first\nk2:"+testKey('2')+"\n\nk1:"+testKey('1')+"\n"), 0o600))
+
+	useKeys(t, FileProvider{Path: path})
+	assert.Equal(t, "k2", Keys().Primary())
+	assert.Equal(t, []string{"k1", "k2", LegacyKeyID}, Keys().IDs())
+}
+
+func TestKeyringRejectsInvalidKeys(t *testing.T) {
+	keys := NewKeyring()
+	assert.Error(t, keys.Add("short", []byte("too short")))
+	assert.Error(t, keys.Add("bad:id", make([]byte, 32)))
+	assert.Error(t, keys.SetPrimary(LegacyKeyID))
+	assert.Error(t, keys.SetPrimary("missing"))
+	assert.Error(t, EnvProvider{Keys: ""}.Load(keys))
+}
+
+func TestEncryptRequiresPrimaryKey(t *testing.T) {
+	previousKeyring := keyring
+	t.Cleanup(func() { keyring = previousKeyring })
+	keyring = NewKeyring()
+
+	_, err := Encrypt("s3cret")
+	assert.Error(t, err)
+}
+
+func TestDigestIsKeyedAndSurvivesRotation(t *testing.T) {
+	useKeys(t, EnvProvider{Keys: "old:" + testKey('o')})
+	sum, err := Digest([]byte("PASSWORD=hunter2"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sum, "old:"))
+	plain := sha256.Sum256([]byte("PASSWORD=hunter2"))
+	assert.NotContains(t, sum, hex.EncodeToString(plain[:]))
+
+	require.NoError(t, Init(EnvProvider{Keys: "new:" + testKey('n') + ",old:" + testKey('o')}))
+	assert.True(t, MatchesDigest([]byte("PASSWORD=hunter2"), sum))
+	assert.False(t, MatchesDigest([]byte("PASSWORD=letmein"), sum))
+	rotated, err := Digest([]byte("PASSWORD=hunter2"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rotated, "new:"))
+	assert.False(t, MatchesDigest([]byte("PASSWORD=hunter2"), "legacy:"+strings.TrimPrefix(sum, "old:")))
+}
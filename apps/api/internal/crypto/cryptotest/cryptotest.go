@@ -0,0 +1,24 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+// Package cryptotest provides the encryption keys tests run with.
+package cryptotest
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"docker-manager/api/internal/crypto"
+)
+
+// KeyID identifies the key Init makes primary.
+const KeyID = "test"
+
+// Init makes a fixed key the primary key, as the server does at startup, so
+// tests can encrypt values.
+func Init() error {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("t", 32)))
+	return crypto.Init(crypto.EnvProvider{Keys: KeyID + ":" + key})
+}
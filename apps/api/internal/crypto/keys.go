@@ -0,0 +1,332 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package crypto
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-manager/api/internal/config"
+)
+
+// LegacyKeyID identifies the shared key used before keys became configurable.
+// Values encrypted before key IDs were introduced carry no ID and use this key.
+const LegacyKeyID = "legacy"
+
+// keyIDPattern restricts key IDs so they can be embedded in ciphertexts and file names.
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Keyring holds the keys values can be decrypted with and the primary key new
+// values are encrypted with. It is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	primary string
+}
+
+// NewKeyring returns a keyring that only knows the legacy key, which is never primary.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: map[string][]byte{LegacyKeyID: config.LegacyEncryptionKey}}
+}
+
+// Add registers a key under id. Keys must be 32 bytes long for AES-256.
+func (k *Keyring) Add(id string, key []byte) error {
+	if !keyIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid key ID %q", id)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("key %q must be 32 bytes long, got %d", id, len(key))
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = key
+	return nil
+}
+
+// SetPrimary makes a registered key the one new values are encrypted with.
+func (k *Keyring) SetPrimary(id string) error {
+	if id == LegacyKeyID {
+		return errors.New("the legacy key cannot be the primary key")
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("unknown key %q", id)
+	}
+	k.primary = id
+	return nil
+}
+
+// Primary returns the ID of the primary key.
+func (k *Keyring) Primary() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// IDs returns the IDs of all registered keys in alphabetical order.
+func (k *Keyring) IDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// key returns the key registered under id.
+func (k *Keyring) key(id string) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", id)
+	}
+	return key, nil
+}
+
+// primaryKey returns the primary key and its ID. The legacy key is public, so
+// nothing is encrypted before a primary key has been set.
+func (k *Keyring) primaryKey() (string, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.primary == "" {
+		return "", nil, errors.New("no primary encryption key has been set")
+	}
+	return k.primary, k.keys[k.primary], nil
+}
+
+// KeyProvider loads encryption keys into a keyring and selects its primary key.
+type KeyProvider interface {
+	Load(keyring *Keyring) error
+}
+
+// KeyGenerator is implemented by providers that can create and store new keys.
+type KeyGenerator interface {
+	// GenerateKey creates a new key, stores it as the primary key and returns its ID.
+	GenerateKey() (string, error)
+}
+
+// EnvProvider reads keys from a list of comma separated "id:base64-key" pairs,
+// typically taken from an environment variable. The first key is the primary key.
+type EnvProvider struct {
+	Keys string
+}
+
+// Load implements KeyProvider.
+func (p EnvProvider) Load(keyring *Keyring) error {
+	return loadKeyList(keyring, strings.Split(p.Keys, ","))
+}
+
+// FileProvider reads keys from a file with one "id:base64-key" pair per line.
+// Empty lines and lines starting with # are ignored. The first key is the primary key.
+type FileProvider struct {
+	Path string
+}
+
+// Load implements KeyProvider.
+func (p FileProvider) Load(keyring *Keyring) error {
+	file, err := os.Open(p.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return loadKeyList(keyring, lines)
+}
+
+// loadKeyList adds "id:base64-key" entries to a keyring and makes the first one primary.
+func loadKeyList(keyring *Keyring, entries []string) error {
+	primary := ""
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return errors.New("encryption keys must be written as id:base64-key")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		if err := keyring.Add(id, key); err != nil {
+			return err
+		}
+		if primary == "" {
+			primary = id
+		}
+	}
+	if primary == "" {
+		return errors.New("no encryption key configured")
+	}
+	return keyring.SetPrimary(primary)
+}
+
+// LocalKMSProvider keeps keys in a local directory, one "<id>.key" file per key
+// plus a "primary" file naming the primary key. A key is generated on first
+// use, so every install gets its own key without any configuration.
+type LocalKMSProvider struct {
+	Dir string
+
+	keyring *Keyring
+}
+
+// Load implements KeyProvider.
+func (p *LocalKMSProvider) Load(keyring *Keyring) error {
+	p.keyring = keyring
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".key")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(p.Dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		if err := keyring.Add(id, key); err != nil {
+			return err
+		}
+	}
+
+	primary, err := os.ReadFile(filepath.Join(p.Dir, "primary"))
+	if errors.Is(err, os.ErrNotExist) {
+		_, err = p.GenerateKey()
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return keyring.SetPrimary(strings.TrimSpace(string(primary)))
+}
+
+// GenerateKey implements KeyGenerator.
+func (p *LocalKMSProvider) GenerateKey() (string, error) {
+	if p.keyring == nil {
+		return "", errors.New("provider has not been loaded")
+	}
+	key := make([]byte, 32)
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	id := time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+
+	if err := os.MkdirAll(p.Dir, 0o700); err != nil {
+		return "", err
+	}
+	encoded := base64.StdEncoding.EncodeToString(key)
+	if err := os.WriteFile(filepath.Join(p.Dir, id+".key"), []byte(encoded+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	if err := p.keyring.Add(id, key); err != nil {
+		return "", err
+	}
+	// Write the new primary atomically so a crash never leaves it pointing at nothing.
+	tmp := filepath.Join(p.Dir, "primary.tmp")
+	if err := os.WriteFile(tmp, []byte(id+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, filepath.Join(p.Dir, "primary")); err != nil {
+		return "", err
+	}
+	return id, p.keyring.SetPrimary(id)
+}
+
+// NewKeyProvider returns the key provider selected by the settings.
+func NewKeyProvider(settings config.Encryption) (KeyProvider, error) {
+	provider := settings.Provider
+	if provider == "" {
+		switch {
+		case settings.Keys != "":
+			provider = "env"
+		case settings.KeyFile != "":
+			provider = "file"
+		default:
+			provider = "local"
+		}
+	}
+
+	switch provider {
+	case "env":
+		return EnvProvider{Keys: settings.Keys}, nil
+	case "file":
+		return FileProvider{Path: settings.KeyFile}, nil
+	case "local":
+		return &LocalKMSProvider{Dir: settings.KeystoreDir}, nil
+	}
+	return nil, fmt.Errorf("unknown key provider %q", provider)
+}
+
+var (
+	keyring  = NewKeyring()
+	provider KeyProvider
+)
+
+// Init loads the encryption keys used by Encrypt and Decrypt from p.
+func Init(p KeyProvider) error {
+	provider = p
+	return Reload()
+}
+
+// Reload reloads the keys from the provider, picking up keys and primary key
+// changes without a restart. Keys that disappeared from the provider are kept
+// until the process exits so values encrypted with them stay readable.
+func Reload() error {
+	if provider == nil {
+		return errors.New("encryption keys have not been initialised")
+	}
+	return provider.Load(keyring)
+}
+
+// GenerateKey creates a new primary key if the provider supports it.
+func GenerateKey() (string, error) {
+	generator, ok := provider.(KeyGenerator)
+	if !ok {
+		return "", errors.New("the configured key provider cannot generate keys")
+	}
+	return generator.GenerateKey()
+}
+
+// Keys returns the keyring used by Encrypt and Decrypt.
+func Keys() *Keyring {
+	return keyring
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package database
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// copyBatchSize is the number of rows copied per insert.
+const copyBatchSize = 500
+
+// Copy copies every row of the tables of models from src into dst, such as
+// from the SQLite database of an install moving to PostgreSQL. Models must be
+// listed with the tables they reference first. dst must have the schema of
+// models already but not hold any data yet, and tables missing from src are
+// left empty. Rows are copied as stored, including soft-deleted ones, without
+// running hooks, so encrypted values stay encrypted with the same keys. It
+// returns the number of rows copied per table.
+func Copy(src, dst *gorm.DB, models ...interface{}) (map[string]int64, error) {
+	for _, model := range models {
+		var count int64
+		if err := dst.Unscoped().Model(model).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("the target database is not empty: %s has %d rows", tableName(dst, model), count)
+		}
+	}
+
+	src = src.Unscoped().Session(&gorm.Session{SkipHooks: true})
+	copied := make(map[string]int64, len(models))
+	err := dst.Session(&gorm.Session{SkipHooks: true}).Transaction(func(tx *gorm.DB) error {
+		for _, model := range models {
+			table := tableName(tx, model)
+			// Databases of older versions may not have every table yet.
+			if !src.Migrator().HasTable(model) {
+				copied[table] = 0
+				continue
+			}
+			rows := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem())).Interface()
+			result := src.Model(model).FindInBatches(rows, copyBatchSize, func(batch *gorm.DB, _ int) error {
+				return tx.Omit(clause.Associations).Create(rows).Error
+			})
+			if result.Error != nil {
+				return fmt.Errorf("copy %s: %w", table, result.Error)
+			}
+			copied[table] = result.RowsAffected
+			if err := resetSequence(tx, model); err != nil {
+				return fmt.Errorf("reset sequence of %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// tableName returns the name of the table of a model.
+func tableName(db *gorm.DB, model interface{}) string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return fmt.Sprintf("%T", model)
+	}
+	return stmt.Schema.Table
+}
+
+// resetSequence moves the PostgreSQL sequence generating the IDs of a table
+// past the IDs copied into it, so new rows do not collide with them. SQLite
+// derives new IDs from the rows themselves.
+func resetSequence(tx *gorm.DB, model interface{}) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	field := stmt.Schema.PrioritizedPrimaryField
+	if field == nil || !field.AutoIncrement {
+		return nil
+	}
+	return tx.Exec(
+		fmt.Sprintf("SELECT setval(pg_get_serial_sequence(?, ?), COALESCE((SELECT MAX(%s) FROM %s), 0) + 1, false)",
+			tx.Statement.Quote(field.DBName), tx.Statement.Quote(stmt.Schema.Table)),
+		stmt.Schema.Table, field.DBName,
+	).Error
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/database/databasetest"
+	"docker-manager/api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCopy(t *testing.T) {
+	schema := []interface{}{&models.Project{}, &models.Environment{}, &models.Service{}, &models.EnvironmentVariable{}}
+	src, err := database.Open(config.Database{Driver: config.DriverSQLite, DSN: filepath.Join(t.TempDir(), "dockman.db")}, &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, src.AutoMigrate(schema...))
+
+	project := models.Project{Name: "shop"}
+	require.NoError(t, src.Create(&project).Error)
+	deleted := models.Project{Name: "old"}
+	require.NoError(t, src.Create(&deleted).Error)
+	require.NoError(t, src.Delete(&deleted).Error)
+	environment := models.Environment{Name: "prod", ProjectID: project.ID}
+	require.NoError(t, src.Create(&environment).Error)
+	parent := models.Service{Name: "stack", Type: "compose", EnvironmentID: environment.ID,
+		SubServices: []models.Service{{Name: "web", Type: "container", Image: "nginx", EnvironmentID: environment.ID, Ports: []string{"80:80"}}}}
+	require.NoError(t, src.Create(&parent).Error)
+	// Values are copied as stored, without decrypting and encrypting them again.
+	raw := src.Session(&gorm.Session{SkipHooks: true})
+	require.NoError(t, raw.Create(&models.EnvironmentVariable{Key: "TOKEN", Value: "v2:primary:ciphertext", EnvironmentID: environment.ID}).Error)
+
+	dst, err := databasetest.Open()
+	require.NoError(t, err)
+	require.NoError(t, dst.AutoMigrate(schema...))
+	copied, err := database.Copy(src, dst, schema...)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"projects": 2, "environments": 1, "services": 2, "environment_variables": 1}, copied)
+
+	var projects []models.Project
+	require.NoError(t, dst.Unscoped().Order("id").Find(&projects).Error)
+	require.Len(t, projects, 2)
+	assert.Equal(t, "shop", projects[0].Name)
+	assert.True(t, projects[1].DeletedAt.Valid)
+	var web models.Service
+	require.NoError(t, dst.Where("name = ?", "web").First(&web).Error)
+	assert.Equal(t, parent.ID, *web.ParentServiceID)
+	assert.Equal(t, []string{"80:80"}, web.Ports)
+	var variable models.EnvironmentVariable
+	require.NoError(t, dst.Session(&gorm.Session{SkipHooks: true}).First(&variable).Error)
+	assert.Equal(t, "v2:primary:ciphertext", variable.Value)
+
+	// New rows do not reuse the IDs that were copied.
+	added := models.Project{Name: "new"}
+	require.NoError(t, dst.Create(&added).Error)
+	assert.Equal(t, deleted.ID+1, added.ID)
+
+	_, err = database.Copy(src, dst, schema...)
+	assert.ErrorContains(t, err, "the target database is not empty")
+}
@@ -6,30 +6,54 @@
 package database
 
 import (
+	"fmt"
 	"log"
 
+	"docker-manager/api/internal/config"
+
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 // DB is the shared database connection
 var DB *gorm.DB
 
-// Init initializes the database connection and migrates the schema.
-func Init() {
+// Init initializes the database connection. SQL statements are logged at
+// the debug level, slow ones at the info and warn levels.
+func Init(settings config.Database, logLevel string) {
 	var err error
-	DB, err = gorm.Open(sqlite.Open("dockman.db"), &gorm.Config{})
+	DB, err = Open(settings, &gorm.Config{Logger: logger.Default.LogMode(gormLogLevel(logLevel))})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	log.Println("Database connection established.")
+	log.Printf("Database connection established (%s).", settings.Driver)
+}
+
+// Open connects to the database selected by settings.
+func Open(settings config.Database, gormConfig *gorm.Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch settings.Driver {
+	case config.DriverSQLite:
+		dialector = sqlite.Open(settings.DSN)
+	case config.DriverPostgres:
+		dialector = postgres.Open(settings.DSN)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", settings.Driver)
+	}
+	return gorm.Open(dialector, gormConfig)
 }
 
-// Migrate runs the database migration for all registered models.
-func Migrate(models ...interface{}) {
-	if err := DB.AutoMigrate(models...); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+// gormLogLevel maps a DockMan log level to the level of the gorm logger.
+func gormLogLevel(level string) logger.LogLevel {
+	switch level {
+	case config.LogDebug:
+		return logger.Info
+	case config.LogError:
+		return logger.Error
+	default:
+		return logger.Warn
 	}
-	log.Println("Database migration completed.")
 }
@@ -0,0 +1,44 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+// Package databasetest provides the database tests run against.
+package databasetest
+
+import (
+	"os"
+
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// PostgresDSNEnv names the environment variable holding the connection
+// string of a PostgreSQL database to run the tests against instead of
+// SQLite, such as one started with make test-postgres. Its public schema is
+// dropped by every test, and packages must not run in parallel (go test -p 1).
+const PostgresDSNEnv = "DOCKMAN_TEST_POSTGRES_DSN"
+
+// Open returns an empty database: an in-memory SQLite database, or the
+// PostgreSQL database named by PostgresDSNEnv. Foreign keys are not created
+// on PostgreSQL so tests can create rows without their parents, as SQLite
+// does not enforce them either.
+func Open() (*gorm.DB, error) {
+	dsn := os.Getenv(PostgresDSNEnv)
+	if dsn == "" {
+		return database.Open(config.Database{Driver: config.DriverSQLite, DSN: ":memory:"}, &gorm.Config{})
+	}
+	db, err := database.Open(config.Database{Driver: config.DriverPostgres, DSN: dsn}, &gorm.Config{DisableForeignKeyConstraintWhenMigrating: true})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Exec("DROP SCHEMA IF EXISTS public CASCADE").Error; err != nil {
+		return nil, err
+	}
+	if err := db.Exec("CREATE SCHEMA public").Error; err != nil {
+		return nil, err
+	}
+	return db, nil
+}
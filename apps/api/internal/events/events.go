@@ -0,0 +1,112 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+// Package events fans out the container events observed on the Docker host,
+// such as a container dying or being removed, to the parts of DockMan and the
+// clients interested in them.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	Start        = "start"
+	Die          = "die"
+	OOM          = "oom"
+	HealthStatus = "health_status"
+	Destroy      = "destroy"
+)
+
+// subscriberBuffer is the number of events buffered per subscriber. A
+// subscriber that falls further behind is dropped rather than slowing down
+// everyone else.
+const subscriberBuffer = 64
+
+// Event is a change in the state of a container. Events of containers that
+// belong to a service carry the service, its environment and its project.
+type Event struct {
+	Type          string    `json:"type"`
+	ContainerID   string    `json:"container_id"`
+	ContainerName string    `json:"container_name,omitempty"`
+	Image         string    `json:"image,omitempty"`
+	ServiceID     uint      `json:"service_id,omitempty"`
+	EnvironmentID uint      `json:"environment_id,omitempty"`
+	ProjectID     uint      `json:"project_id,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	Health        string    `json:"health,omitempty"`
+	ExitCode      *int      `json:"exit_code,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// Filter selects the events a subscriber receives.
+type Filter struct {
+	// ProjectIDs restricts events to these projects. Nil means every event,
+	// including those of containers that belong to no service.
+	ProjectIDs    []uint
+	ProjectID     uint
+	EnvironmentID uint
+}
+
+// Match reports whether an event passes the filter.
+func (f Filter) Match(event Event) bool {
+	if f.ProjectID != 0 && event.ProjectID != f.ProjectID {
+		return false
+	}
+	if f.EnvironmentID != 0 && event.EnvironmentID != f.EnvironmentID {
+		return false
+	}
+	if f.ProjectIDs == nil {
+		return true
+	}
+	for _, id := range f.ProjectIDs {
+		if id == event.ProjectID && id != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu          sync.Mutex
+	subscribers = map[chan Event]Filter{}
+)
+
+// Subscribe returns a channel receiving the events that pass filter. The
+// channel is closed by unsubscribe, or when the subscriber falls too far
+// behind.
+func Subscribe(filter Filter) (events <-chan Event, unsubscribe func()) {
+	ch := make(chan Event, subscriberBuffer)
+	mu.Lock()
+	subscribers[ch] = filter
+	mu.Unlock()
+	return ch, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := subscribers[ch]; ok {
+			delete(subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends an event to its subscribers.
+func Publish(event Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	for ch, filter := range subscribers {
+		if !filter.Match(event) {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			delete(subscribers, ch)
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribersOnlyReceiveMatchingEvents(t *testing.T) {
+	all, unsubscribeAll := Subscribe(Filter{})
+	defer unsubscribeAll()
+	member, unsubscribeMember := Subscribe(Filter{ProjectIDs: []uint{1, 2}, EnvironmentID: 3})
+	defer unsubscribeMember()
+
+	Publish(Event{Type: Start, ContainerID: "unmanaged"})
+	Publish(Event{Type: Start, ContainerID: "other-env", ProjectID: 1, EnvironmentID: 4})
+	Publish(Event{Type: Die, ContainerID: "api", ProjectID: 1, EnvironmentID: 3})
+
+	assert.Equal(t, "unmanaged", (<-all).ContainerID)
+	assert.Equal(t, "other-env", (<-all).ContainerID)
+	assert.Equal(t, "api", (<-all).ContainerID)
+	assert.Equal(t, "api", (<-member).ContainerID)
+	assert.Empty(t, member)
+
+	unsubscribeMember()
+	_, open := <-member
+	assert.False(t, open)
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/compose"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ContainerScope resolves the scope of a request from a container ID route
+// parameter. Only containers created for a DockMan service have a scope;
+// other containers are reported as not found.
+func ContainerScope(param string) auth.ScopeResolver {
+	return func(c *gin.Context) (auth.Scope, error) {
+		info, err := DockerClient.ContainerInspect(context.Background(), c.Param(param))
+		if client.IsErrNotFound(err) {
+			return auth.Scope{}, auth.ErrScopeNotFound
+		}
+		if err != nil {
+			return auth.Scope{}, err
+		}
+
+		var labels map[string]string
+		if info.Config != nil {
+			labels = info.Config.Labels
+		}
+		service, err := containerService(info.ID, labels)
+		if err != nil {
+			return auth.Scope{}, err
+		}
+		return auth.ScopeOfEnvironment(service.EnvironmentID)
+	}
+}
+
+// containerService returns the service that owns a container, either directly
+// or through the compose project the container is labelled with.
+func containerService(containerID string, labels map[string]string) (*models.Service, error) {
+	var service models.Service
+	err := database.DB.Where("container_id = ?", containerID).First(&service).Error
+	if err == nil {
+		return &service, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Adopted stacks keep their own project name.
+	projectName := labels[compose.ProjectLabel]
+	if projectName == "" {
+		return nil, auth.ErrScopeNotFound
+	}
+	err = database.DB.Where("type = ? AND compose_project = ?", "compose", projectName).First(&service).Error
+	if err == nil {
+		return &service, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Compose projects DockMan deploys are named "dockman-<service id>-<service name>".
+	rest, ok := strings.CutPrefix(projectName, "dockman-")
+	if !ok {
+		return nil, auth.ErrScopeNotFound
+	}
+	idPart, _, _ := strings.Cut(rest, "-")
+	serviceID, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil {
+		return nil, auth.ErrScopeNotFound
+	}
+	if err := database.DB.First(&service, serviceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrScopeNotFound
+		}
+		return nil, err
+	}
+	if service.Type != "compose" || composeProjectName(&service) != projectName {
+		return nil, auth.ErrScopeNotFound
+	}
+	return &service, nil
+}
+
+// accessibleServices returns the services in the projects visible to the
+// request. It reports true instead when every resource is accessible.
+func accessibleServices(c *gin.Context) ([]models.Service, bool, error) {
+	projectIDs, err := auth.VisibleProjectIDs(c)
+	if err != nil {
+		return nil, false, err
+	}
+	if projectIDs == nil {
+		return nil, true, nil
+	}
+	var services []models.Service
+	err = database.DB.
+		Joins("JOIN environments ON environments.id = services.environment_id AND environments.deleted_at IS NULL").
+		Where("environments.project_id IN ?", projectIDs).
+		Find(&services).Error
+	return services, false, err
+}
+
+// ListAccessibleContainers lists every container for administrators, and
+// only the containers of services in their projects for other users.
+func ListAccessibleContainers(c *gin.Context) {
+	services, all, err := accessibleServices(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list containers"})
+		return
+	}
+	if all {
+		ListContainers(c)
+		return
+	}
+
+	containerIDs := map[string]bool{}
+	projectNames := map[string]bool{}
+	for i := range services {
+		if services[i].ContainerID != "" {
+			containerIDs[services[i].ContainerID] = true
+		}
+		if services[i].Type == "compose" {
+			projectNames[composeProjectName(&services[i])] = true
+		}
+	}
+
+	containers, err := DockerClient.ContainerList(context.Background(), container.ListOptions{All: true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list containers"})
+		return
+	}
+	visible := []types.Container{}
+	for _, ctr := range containers {
+		if containerIDs[ctr.ID] || projectNames[ctr.Labels[compose.ProjectLabel]] {
+			visible = append(visible, ctr)
+		}
+	}
+	c.JSON(http.StatusOK, visible)
+}
+
+// ListAccessibleImages lists every image for administrators, and only the
+// images used by services in their projects for other users.
+func ListAccessibleImages(c *gin.Context) {
+	services, all, err := accessibleServices(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list images"})
+		return
+	}
+	if all {
+		ListImages(c)
+		return
+	}
+
+	used := map[string]bool{}
+	for _, service := range services {
+		if service.Image != "" {
+			used[service.Image] = true
+			if !strings.Contains(service.Image[strings.LastIndex(service.Image, "/")+1:], ":") {
+				used[service.Image+":latest"] = true
+			}
+		}
+	}
+
+	images, err := DockerClient.ImageList(context.Background(), types.ImageListOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list images"})
+		return
+	}
+	visible := []types.ImageSummary{}
+	for _, image := range images {
+		for _, tag := range image.RepoTags {
+			if used[tag] {
+				visible = append(visible, image)
+				break
+			}
+		}
+	}
+	c.JSON(http.StatusOK, visible)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"docker-manager/api/internal/crypto"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ListEncryptionKeys returns the IDs of the loaded encryption keys and of the primary key.
+// Key material is never returned.
+func ListEncryptionKeys(c *gin.Context) {
+	keys := crypto.Keys()
+	c.JSON(http.StatusOK, gin.H{"primary": keys.Primary(), "keys": keys.IDs()})
+}
+
+// RotateEncryptionKey reloads the keys from the key provider, optionally
+// generates a new primary key, and re-encrypts every environment variable,
+// registry password and deployment environment snapshot that is not encrypted
+// with the primary key. Old keys
+// stay loaded, so secrets remain readable while the rotation is running.
+func RotateEncryptionKey(c *gin.Context) {
+	var request struct {
+		Generate bool `json:"generate"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := crypto.Reload(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload encryption keys: " + err.Error()})
+		return
+	}
+	if request.Generate {
+		if _, err := crypto.GenerateKey(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to generate encryption key: " + err.Error()})
+			return
+		}
+	}
+
+	count, err := models.ReencryptEnvironmentVariables(database.DB, crypto.NeedsReencryption, crypto.Decrypt)
+	if err != nil {
+		log.Printf("Error re-encrypting environment variables: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-encrypt environment variables", "reencrypted": count})
+		return
+	}
+	credentials, err := models.ReencryptRegistryCredentials(database.DB, crypto.NeedsReencryption, crypto.Decrypt)
+	count += credentials
+	if err != nil {
+		log.Printf("Error re-encrypting registry credentials: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-encrypt registry credentials", "reencrypted": count})
+		return
+	}
+	deployments, err := models.ReencryptDeployments(database.DB, crypto.NeedsReencryption, crypto.Decrypt)
+	count += deployments
+	if err != nil {
+		log.Printf("Error re-encrypting deployment snapshots: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-encrypt deployment snapshots", "reencrypted": count})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"primary": crypto.Keys().Primary(), "reencrypted": count})
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/backup"
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/crypto"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRotateEncryptionKey(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/admin/encryption/rotate", RotateEncryptionKey)
+
+	oldKey := "old:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32)))
+	newKey := "new:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("n", 32)))
+	require.NoError(t, crypto.Init(crypto.EnvProvider{Keys: oldKey}))
+	database.DB.Create(&models.EnvironmentVariable{Key: "TOKEN", Value: "abc", EnvironmentID: 1})
+	deployment := models.Deployment{ServiceID: 1, Kind: models.DeploymentUp, Status: models.DeploymentSucceeded}
+	require.NoError(t, deployment.SetEnvironment([]string{"TOKEN=abc"}, "hash"))
+	database.DB.Create(&deployment)
+
+	// The operator adds a new primary key in front of the old one.
+	require.NoError(t, crypto.Init(crypto.EnvProvider{Keys: newKey + "," + oldKey}))
+
+	req, _ := http.NewRequest("POST", "/api/admin/encryption/rotate", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"primary": "new", "reencrypted": 2}`, w.Body.String())
+
+	var raw models.EnvironmentVariable
+	database.DB.Session(&gorm.Session{SkipHooks: true}).First(&raw)
+	assert.True(t, strings.HasPrefix(raw.Value, "v2:new:"))
+
+	var variable models.EnvironmentVariable
+	database.DB.First(&variable)
+	assert.Equal(t, "abc", variable.Value)
+
+	require.NoError(t, database.DB.First(&deployment).Error)
+	assert.True(t, strings.HasPrefix(deployment.EnvSnapshot, "v2:new:"))
+	env, err := deployment.Environment()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TOKEN=abc"}, env)
+}
+
+func TestCreateBackup(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/admin/backup", CreateBackup)
+	BackupSettings = config.Backup{Dir: t.TempDir(), Keep: 1}
+	database.DB.Create(&models.Project{Name: "shop"})
+
+	req, _ := http.NewRequest("POST", "/api/admin/backup", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if database.DB.Dialector.Name() != "sqlite" {
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		return
+	}
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created backup.Backup
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, BackupSettings.Dir, filepath.Dir(created.Path))
+	assert.FileExists(t, created.Path)
+}
@@ -0,0 +1,434 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/compose"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Labels the docker compose CLI sets on the containers of a stack, used to
+// reconstruct stacks started outside of DockMan.
+const (
+	composeConfigFilesLabel = "com.docker.compose.project.config_files"
+	composeWorkingDirLabel  = "com.docker.compose.project.working_dir"
+	composeDependsOnLabel   = "com.docker.compose.depends_on"
+)
+
+// adoptableGroup is a set of containers no service owns: the containers of a
+// compose stack, or standalone containers when ComposeProject is empty.
+type adoptableGroup struct {
+	ComposeProject string            `json:"compose_project,omitempty"`
+	ComposePath    string            `json:"compose_path,omitempty"`
+	Containers     []types.Container `json:"containers"`
+}
+
+// variableImport reports the variables copied into an environment from the
+// containers adopted into it. Variables the environment already defines with
+// another value are left untouched and reported as conflicts.
+type variableImport struct {
+	Imported  []string `json:"imported"`
+	Conflicts []string `json:"conflicts"`
+}
+
+// unownedContainers lists the containers no service owns, leaving out the
+// ones DockMan created itself, even for services deleted since.
+func unownedContainers(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	containers, err := DockerClient.ContainerList(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	unowned := []types.Container{}
+	for _, ctr := range containers {
+		if ctr.Labels[serviceLabel] != "" || ctr.Labels[helperLabel] != "" {
+			continue
+		}
+		_, err := containerService(ctr.ID, ctr.Labels)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, auth.ErrScopeNotFound) {
+			return nil, err
+		}
+		unowned = append(unowned, ctr)
+	}
+	return unowned, nil
+}
+
+// composePath returns the compose file a stack was started from, as recorded
+// by the docker compose CLI.
+func composePath(labels map[string]string) string {
+	file, _, _ := strings.Cut(labels[composeConfigFilesLabel], ",")
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(labels[composeWorkingDirLabel], file)
+}
+
+// ListAdoptableContainers lists the containers no service owns, grouped by
+// the compose stack they belong to. Standalone containers come first.
+func ListAdoptableContainers(c *gin.Context) {
+	containers, err := unownedContainers(c.Request.Context(), container.ListOptions{All: true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list containers"})
+		return
+	}
+
+	standalone := adoptableGroup{Containers: []types.Container{}}
+	stacks := map[string]*adoptableGroup{}
+	for _, ctr := range containers {
+		project := ctr.Labels[compose.ProjectLabel]
+		if project == "" {
+			standalone.Containers = append(standalone.Containers, ctr)
+			continue
+		}
+		if stacks[project] == nil {
+			stacks[project] = &adoptableGroup{ComposeProject: project, ComposePath: composePath(ctr.Labels)}
+		}
+		stacks[project].Containers = append(stacks[project].Containers, ctr)
+	}
+
+	groups := []adoptableGroup{}
+	if len(standalone.Containers) > 0 {
+		groups = append(groups, standalone)
+	}
+	names := make([]string, 0, len(stacks))
+	for name := range stacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		groups = append(groups, *stacks[name])
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// AdoptContainers attaches containers started outside of DockMan to an
+// environment: either standalone containers, each becoming a container
+// service, or a whole compose stack, becoming a compose service with a
+// sub-service per compose service. Their image, ports and mounts are read
+// back from Docker, and their variables are copied into the environment.
+// The containers keep running untouched until the services are next deployed.
+func AdoptContainers(c *gin.Context) {
+	var input struct {
+		ContainerIDs   []string `json:"container_ids"`
+		ComposeProject string   `json:"compose_project"`
+		Name           string   `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (len(input.ContainerIDs) == 0) == (input.ComposeProject == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either container_ids or compose_project"})
+		return
+	}
+	var environment models.Environment
+	if err := database.DB.First(&environment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var options container.ListOptions
+	if input.ComposeProject != "" {
+		options = container.ListOptions{All: true, Filters: filters.NewArgs(filters.Arg("label", compose.ProjectLabel+"="+input.ComposeProject))}
+	} else {
+		options = container.ListOptions{All: true, Filters: filters.NewArgs()}
+		for _, id := range input.ContainerIDs {
+			options.Filters.Add("id", id)
+		}
+	}
+	containers, err := unownedContainers(ctx, options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list containers"})
+		return
+	}
+
+	var adopted []models.Service
+	var variables variableImport
+	if input.ComposeProject != "" {
+		if len(containers) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No unmanaged containers belong to this compose project"})
+			return
+		}
+		var service *models.Service
+		service, variables, err = adoptStack(ctx, &environment, input.ComposeProject, input.Name, containers)
+		if service != nil {
+			adopted = append(adopted, *service)
+		}
+	} else {
+		adopted, variables, err = adoptStandalone(ctx, &environment, input.ContainerIDs, containers)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to adopt containers: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"services": adopted, "variables": variables})
+}
+
+// adoptStandalone creates a container service for each of the containers with
+// the given IDs, which must all be unowned and outside of any compose stack.
+func adoptStandalone(ctx context.Context, environment *models.Environment, ids []string, unowned []types.Container) ([]models.Service, variableImport, error) {
+	byID := map[string]types.Container{}
+	for _, ctr := range unowned {
+		byID[ctr.ID] = ctr
+	}
+	var services []models.Service
+	var envs [][]string
+	for _, id := range ids {
+		ctr, ok := findContainer(byID, id)
+		if !ok {
+			return nil, variableImport{}, fmt.Errorf("container %s does not exist or is already managed", id)
+		}
+		if project := ctr.Labels[compose.ProjectLabel]; project != "" {
+			return nil, variableImport{}, fmt.Errorf("container %s belongs to the compose project %s, adopt the project instead", id, project)
+		}
+		service, env, err := reconstructService(ctx, ctr.ID)
+		if err != nil {
+			return nil, variableImport{}, err
+		}
+		service.EnvironmentID = environment.ID
+		service.ContainerID = ctr.ID
+		services = append(services, *service)
+		envs = append(envs, env)
+	}
+
+	var variables variableImport
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&services).Error; err != nil {
+			return err
+		}
+		var err error
+		variables, err = importVariables(tx, environment.ID, envs...)
+		return err
+	})
+	return services, variables, err
+}
+
+// findContainer looks up a container by its full ID or a unique prefix of it,
+// as Docker accepts.
+func findContainer(byID map[string]types.Container, id string) (types.Container, bool) {
+	if ctr, ok := byID[id]; ok {
+		return ctr, true
+	}
+	var found []types.Container
+	for full, ctr := range byID {
+		if strings.HasPrefix(full, id) {
+			found = append(found, ctr)
+		}
+	}
+	if len(found) != 1 {
+		return types.Container{}, false
+	}
+	return found[0], true
+}
+
+// adoptStack creates a compose service for a stack started outside of
+// DockMan, with a sub-service per compose service. The stack keeps its
+// project name so DockMan keeps managing the same containers.
+func adoptStack(ctx context.Context, environment *models.Environment, project, name string, containers []types.Container) (*models.Service, variableImport, error) {
+	if name == "" {
+		name = project
+	}
+	parent := models.Service{
+		Name:           name,
+		Type:           "compose",
+		EnvironmentID:  environment.ID,
+		ComposePath:    composePath(containers[0].Labels),
+		ComposeProject: project,
+	}
+
+	// The first replica of each compose service stands for all of them.
+	sort.Slice(containers, func(i, j int) bool {
+		a, _ := strconv.Atoi(containers[i].Labels[compose.NumberLabel])
+		b, _ := strconv.Atoi(containers[j].Labels[compose.NumberLabel])
+		return a < b
+	})
+	var envs [][]string
+	seen := map[string]bool{}
+	for _, ctr := range containers {
+		serviceName := ctr.Labels[compose.ServiceLabel]
+		if seen[serviceName] {
+			continue
+		}
+		seen[serviceName] = true
+		subService, env, err := reconstructService(ctx, ctr.ID)
+		if err != nil {
+			return nil, variableImport{}, err
+		}
+		// Sub-services describe their containers the way compose files do.
+		subService.Ports = publishedPorts(subService.Spec)
+		subService.Volumes = mountedVolumes(subService.Spec)
+		subService.Spec = nil
+		subService.Name = serviceName
+		subService.EnvironmentID = environment.ID
+		subService.DependsOn = composeDependencies(ctr.Labels[composeDependsOnLabel])
+		parent.SubServices = append(parent.SubServices, *subService)
+		envs = append(envs, env)
+	}
+	sort.Slice(parent.SubServices, func(i, j int) bool { return parent.SubServices[i].Name < parent.SubServices[j].Name })
+
+	var variables variableImport
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Service{}).Where("type = ? AND compose_project = ?", "compose", project).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("the compose project %s is already adopted", project)
+		}
+		// Sub-services are created with their parent, which sets their parent_service_id.
+		if err := tx.Create(&parent).Error; err != nil {
+			return err
+		}
+		var err error
+		variables, err = importVariables(tx, environment.ID, envs...)
+		return err
+	})
+	if err != nil {
+		return nil, variableImport{}, err
+	}
+	return &parent, variables, nil
+}
+
+// composeDependencies parses the services listed in the depends_on label the
+// docker compose CLI sets, such as "db:service_started:false,cache:...".
+func composeDependencies(label string) []string {
+	var dependencies []string
+	for _, entry := range strings.Split(label, ",") {
+		if name, _, _ := strings.Cut(entry, ":"); name != "" {
+			dependencies = append(dependencies, name)
+		}
+	}
+	return dependencies
+}
+
+// reconstructService reads back the configuration of a container: its
+// image, its spec and the variables set on it rather than inherited from its
+// image.
+func reconstructService(ctx context.Context, containerID string) (*models.Service, []string, error) {
+	info, err := DockerClient.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Config == nil {
+		return nil, nil, fmt.Errorf("container %s has no configuration", containerID)
+	}
+	service := &models.Service{
+		Name:   strings.TrimPrefix(info.Name, "/"),
+		Type:   "container",
+		Image:  info.Config.Image,
+		Status: containerStatus(stateOf(info)),
+		Spec:   inspectedSpec(info),
+	}
+
+	var inherited []string
+	if image, _, err := DockerClient.ImageInspectWithRaw(ctx, info.Image); err == nil && image.Config != nil {
+		inherited = image.Config.Env
+	}
+	var env []string
+	for _, pair := range info.Config.Env {
+		if !slices.Contains(inherited, pair) {
+			env = append(env, pair)
+		}
+	}
+	return service, env, nil
+}
+
+// stateOf returns the state of an inspected container, such as "running".
+func stateOf(info types.ContainerJSON) string {
+	if info.State == nil {
+		return ""
+	}
+	return info.State.Status
+}
+
+// publishedPorts formats the ports of a spec the way compose files declare
+// them, such as "127.0.0.1:8080:80" or "53:53/udp".
+func publishedPorts(spec *models.ContainerSpec) []string {
+	var published []string
+	for _, port := range spec.Ports {
+		entry := strconv.Itoa(port.ContainerPort)
+		if port.Proto() != "tcp" {
+			entry += "/" + port.Proto()
+		}
+		if port.HostPort != 0 {
+			entry = strconv.Itoa(port.HostPort) + ":" + entry
+		}
+		if port.HostIP != "" {
+			entry = port.HostIP + ":" + entry
+		}
+		published = append(published, entry)
+	}
+	return published
+}
+
+// mountedVolumes formats the mounts of a spec the way compose files declare
+// them, such as "data:/data" or "/etc/nginx:/etc/nginx:ro".
+func mountedVolumes(spec *models.ContainerSpec) []string {
+	var volumes []string
+	for _, m := range spec.Mounts {
+		entry := m.Source + ":" + m.Target
+		if m.ReadOnly {
+			entry += ":ro"
+		}
+		volumes = append(volumes, entry)
+	}
+	return volumes
+}
+
+// importVariables copies KEY=value variables into an environment. Variables
+// it already defines are kept: they are reported as conflicts when the values
+// differ.
+func importVariables(tx *gorm.DB, environmentID uint, envs ...[]string) (variableImport, error) {
+	result := variableImport{Imported: []string{}, Conflicts: []string{}}
+	var existing []models.EnvironmentVariable
+	if err := tx.Where("environment_id = ?", environmentID).Find(&existing).Error; err != nil {
+		return result, err
+	}
+	values := make(map[string]string, len(existing))
+	for _, variable := range existing {
+		values[variable.Key] = variable.Value
+	}
+
+	for _, env := range envs {
+		for _, pair := range env {
+			key, value, _ := strings.Cut(pair, "=")
+			current, defined := values[key]
+			switch {
+			case !defined:
+				variable := models.EnvironmentVariable{Key: key, Value: value, EnvironmentID: environmentID}
+				if err := tx.Create(&variable).Error; err != nil {
+					return result, err
+				}
+				values[key] = value
+				result.Imported = append(result.Imported, key)
+			case current != value && !slices.Contains(result.Conflicts, key):
+				result.Conflicts = append(result.Conflicts, key)
+			}
+		}
+	}
+	return result, nil
+}
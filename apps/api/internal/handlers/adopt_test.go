@@ -0,0 +1,152 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"docker-manager/api/internal/compose"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// inspected returns the inspect result of a running container.
+func inspected(name, image string, env []string, mounts []types.MountPoint, ports nat.PortMap) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			Name:       "/" + name,
+			Image:      "sha256:" + name,
+			State:      &types.ContainerState{Status: "running"},
+			HostConfig: &container.HostConfig{PortBindings: ports},
+		},
+		Mounts: mounts,
+		Config: &container.Config{Image: image, Env: env},
+	}
+}
+
+func TestAdoptStandaloneContainer(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/environments/:id/adopt", AdoptContainers)
+	environment := models.Environment{Name: "prod", ProjectID: 1}
+	require.NoError(t, database.DB.Create(&environment).Error)
+	require.NoError(t, database.DB.Create(&models.EnvironmentVariable{Key: "TZ", Value: "UTC", EnvironmentID: environment.ID}).Error)
+
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{{ID: "abc123"}}, nil)
+	mockClient.On("ContainerInspect", mock.Anything, "abc123").Return(inspected("legacy-web", "nginx:1.25",
+		[]string{"PATH=/usr/bin", "TZ=Europe/Paris", "API_URL=http://api"},
+		[]types.MountPoint{
+			{Type: mount.TypeVolume, Name: "webdata", Destination: "/data", RW: true},
+			{Type: mount.TypeBind, Source: "/etc/nginx", Destination: "/etc/nginx"},
+		},
+		nat.PortMap{"80/tcp": {{HostIP: "127.0.0.1", HostPort: "8080"}}, "53/udp": {{HostPort: "53"}}},
+	), nil)
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "sha256:legacy-web").Return(types.ImageInspect{Config: &container.Config{Env: []string{"PATH=/usr/bin"}}}, []byte{}, nil)
+
+	w := doRequest(router, "POST", "/api/environments/1/adopt", `{"container_ids": ["abc"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Services  []models.Service `json:"services"`
+		Variables variableImport   `json:"variables"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Services, 1)
+	service := response.Services[0]
+	assert.Equal(t, "legacy-web", service.Name)
+	assert.Equal(t, "nginx:1.25", service.Image)
+	assert.Equal(t, "abc123", service.ContainerID)
+	assert.Equal(t, models.ServiceRunning, service.Status)
+	require.NotNil(t, service.Spec)
+	assert.Equal(t, []models.PortBinding{
+		{HostPort: 53, ContainerPort: 53, Protocol: "udp"},
+		{HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+	}, service.Spec.Ports)
+	assert.Equal(t, []models.Mount{
+		{Type: models.MountVolume, Source: "webdata", Target: "/data"},
+		{Type: models.MountBind, Source: "/etc/nginx", Target: "/etc/nginx", ReadOnly: true},
+	}, service.Spec.Mounts)
+	assert.NoError(t, service.Spec.Validate())
+
+	// Variables baked into the image are left out, and existing ones win.
+	assert.Equal(t, variableImport{Imported: []string{"API_URL"}, Conflicts: []string{"TZ"}}, response.Variables)
+	env, err := environmentVariableList(environment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"API_URL=http://api", "TZ=UTC"}, env)
+}
+
+func TestAdoptComposeStack(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.GET("/api/adoptable", ListAdoptableContainers)
+	router.POST("/api/environments/:id/adopt", AdoptContainers)
+	environment := models.Environment{Name: "prod", ProjectID: 1}
+	require.NoError(t, database.DB.Create(&environment).Error)
+
+	stack := func(service string) map[string]string {
+		return map[string]string{
+			compose.ProjectLabel:    "blog",
+			compose.ServiceLabel:    service,
+			compose.NumberLabel:     "1",
+			composeConfigFilesLabel: "docker-compose.yml",
+			composeWorkingDirLabel:  "/srv/blog",
+		}
+	}
+	wordpress := stack("wordpress")
+	wordpress[composeDependsOnLabel] = "db:service_started:false"
+	containers := []types.Container{
+		{ID: "wp", Labels: wordpress},
+		{ID: "db", Labels: stack("db")},
+		{ID: "standalone"},
+		{ID: "dockman-owned", Labels: map[string]string{serviceLabel: "9"}},
+	}
+	mockClient.On("ContainerList", mock.Anything, mock.MatchedBy(func(options container.ListOptions) bool {
+		return slices.Contains(options.Filters.Get("label"), compose.ProjectLabel+"=blog")
+	})).Return(containers[:2], nil)
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+
+	w := doRequest(router, "GET", "/api/adoptable", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var groups []adoptableGroup
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &groups))
+	require.Len(t, groups, 2)
+	assert.Equal(t, "standalone", groups[0].Containers[0].ID)
+	assert.Equal(t, "blog", groups[1].ComposeProject)
+	assert.Equal(t, "/srv/blog/docker-compose.yml", groups[1].ComposePath)
+	assert.Len(t, groups[1].Containers, 2)
+
+	mockClient.On("ContainerInspect", mock.Anything, "wp").Return(inspected("blog-wordpress-1", "wordpress:6", nil, nil, nil), nil)
+	mockClient.On("ContainerInspect", mock.Anything, "db").Return(inspected("blog-db-1", "mariadb:11", []string{"MARIADB_DATABASE=blog"}, nil, nil), nil)
+	mockClient.On("ImageInspectWithRaw", mock.Anything, mock.Anything).Return(types.ImageInspect{}, []byte{}, nil)
+	w = doRequest(router, "POST", "/api/environments/1/adopt", `{"compose_project": "blog", "name": "Blog"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var parent models.Service
+	require.NoError(t, database.DB.Preload("SubServices").Where("compose_project = ?", "blog").First(&parent).Error)
+	assert.Equal(t, "Blog", parent.Name)
+	assert.Equal(t, "/srv/blog/docker-compose.yml", parent.ComposePath)
+	require.Len(t, parent.SubServices, 2)
+	assert.Equal(t, "db", parent.SubServices[0].Name)
+	assert.Equal(t, "mariadb:11", parent.SubServices[0].Image)
+	assert.Equal(t, []string{"db"}, parent.SubServices[1].DependsOn)
+
+	// The stack keeps its name and now belongs to the service.
+	owner, err := containerService("wp", wordpress)
+	require.NoError(t, err)
+	assert.Equal(t, parent.ID, owner.ID)
+	w = doRequest(router, "POST", "/api/environments/1/adopt", `{"compose_project": "blog"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
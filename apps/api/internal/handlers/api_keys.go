@@ -0,0 +1,123 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKey creates an API key for the signed-in user. The secret is only
+// returned in this response.
+func CreateAPIKey(c *gin.Context) {
+	var input struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		ProjectID *uint      `json:"project_id"`
+		RateLimit int        `json:"rate_limit"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required"})
+		return
+	}
+	for _, scope := range input.Scopes {
+		if !models.ValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Scopes must be read, write, deploy or admin"})
+			return
+		}
+	}
+	if input.RateLimit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rate limit cannot be negative"})
+		return
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		return
+	}
+
+	user := auth.CurrentUser(c)
+	if input.ProjectID != nil {
+		role, err := auth.EffectiveRole(user, auth.Scope{ProjectID: *input.ProjectID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+		if role == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Project not found"})
+			return
+		}
+	}
+	if input.RateLimit == 0 {
+		input.RateLimit = auth.DefaultRateLimit
+	}
+
+	secret, prefix, err := auth.NewAPIKeySecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+	key := models.APIKey{
+		Name:       input.Name,
+		Prefix:     prefix,
+		SecretHash: auth.HashToken(secret),
+		Scopes:     input.Scopes,
+		UserID:     user.ID,
+		ProjectID:  input.ProjectID,
+		RateLimit:  input.RateLimit,
+		ExpiresAt:  input.ExpiresAt,
+	}
+	if err := database.DB.Create(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"key": key, "secret": secret})
+}
+
+// ListAPIKeys lists the API keys of the signed-in user, or of every user for
+// administrators passing ?all=true.
+func ListAPIKeys(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	query := database.DB.Order("created_at DESC")
+	if !user.IsAdmin || c.Query("all") != "true" {
+		query = query.Where("user_id = ?", user.ID)
+	}
+
+	var keys []models.APIKey
+	if err := query.Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey revokes an API key. Users can revoke their own keys and
+// administrators any key.
+func RevokeAPIKey(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	var key models.APIKey
+	if err := database.DB.First(&key, c.Param("id")).Error; err != nil || (key.UserID != user.ID && !user.IsAdmin) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if key.RevokedAt == nil {
+		if err := auth.RevokeAPIKey(&key); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func bearerRequest(router *gin.Engine, method, path, secret string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+secret)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func createKey(t *testing.T, router *gin.Engine, cookie *http.Cookie, body string) (models.APIKey, string) {
+	w := doRequest(router, "POST", "/api/keys", body, cookie)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Key    models.APIKey `json:"key"`
+		Secret string        `json:"secret"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Key, response.Secret
+}
+
+func TestAPIKeyDeploysServiceWithinScopeAndRateLimit(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupRBACRouter(mockClient)
+	keys := router.Group("/api/keys", auth.Required(), auth.SessionRequired())
+	keys.POST("", CreateAPIKey)
+	keys.GET("", ListAPIKeys)
+	keys.DELETE("/:id", RevokeAPIKey)
+
+	w := doRequest(router, "POST", "/api/auth/setup", `{"username": "admin", "password": "correct horse"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	admin := sessionCookie(t, w)
+	database.DB.Create(&models.Project{Name: "shop"})
+	database.DB.Create(&models.Project{Name: "other"})
+	environment := models.Environment{Name: "dev", ProjectID: 1}
+	database.DB.Create(&environment)
+	service := models.Service{Name: "web", Type: "container", Image: "nginx:latest", EnvironmentID: environment.ID}
+	database.DB.Create(&service)
+
+	mockClient.On("NetworkInspect", mock.Anything, mock.Anything, mock.Anything).Return(types.NetworkResource{}, nil)
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "nginx:latest").Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(container.CreateResponse{ID: "ci-container"}, nil)
+	mockClient.On("ContainerStart", mock.Anything, "ci-container", mock.Anything).Return(nil)
+
+	up := fmt.Sprintf("/api/services/%d/up", service.ID)
+	deployKey, deploySecret := createKey(t, router, admin, `{"name": "ci", "scopes": ["deploy"], "project_id": 1, "rate_limit": 2}`)
+	assert.Equal(t, deploySecret[:len(deployKey.Prefix)], deployKey.Prefix)
+
+	w = bearerRequest(router, "POST", up, deploySecret)
+	assert.Equal(t, models.JobSucceeded, waitForJob(t, w).Status)
+	w = bearerRequest(router, "GET", "/api/projects", deploySecret)
+	assert.JSONEq(t, `[{"ID":1}]`, projectIDs(t, w))
+	w = bearerRequest(router, "POST", up, deploySecret)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// Keys cannot manage keys, and read-only keys cannot deploy.
+	_, readSecret := createKey(t, router, admin, `{"name": "dashboard", "scopes": ["read"]}`)
+	w = bearerRequest(router, "GET", "/api/keys", readSecret)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = bearerRequest(router, "POST", up, readSecret)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = bearerRequest(router, "GET", "/api/projects/1", readSecret)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Only unbound keys with the admin scope create projects, which their
+	// user becomes the admin of.
+	w = bearerRequest(router, "POST", "/api/projects", readSecret)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	_, writeSecret := createKey(t, router, admin, `{"name": "ci", "scopes": ["write"]}`)
+	w = bearerRequest(router, "POST", "/api/projects", writeSecret)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	_, boundAdminSecret := createKey(t, router, admin, `{"name": "bound", "scopes": ["admin"], "project_id": 1}`)
+	w = bearerRequest(router, "POST", "/api/projects", boundAdminSecret)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var projects int64
+	database.DB.Model(&models.Project{}).Count(&projects)
+	assert.Equal(t, int64(2), projects)
+
+	// Revoked keys stop working.
+	w = doRequest(router, "DELETE", fmt.Sprintf("/api/keys/%d", deployKey.ID), "", admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = bearerRequest(router, "POST", up, deploySecret)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = bearerRequest(router, "GET", "/api/projects", "dmk_unknown")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// projectIDs reduces a project list response to the project IDs.
+func projectIDs(t *testing.T, w *httptest.ResponseRecorder) string {
+	var projects []struct{ ID uint }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &projects))
+	data, _ := json.Marshal(projects)
+	return string(data)
+}
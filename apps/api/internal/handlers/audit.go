@@ -0,0 +1,116 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditQuery builds the query for audit events from the filters of a request:
+// project_id, actor (a username or user ID), action, since and until (RFC 3339).
+func auditQuery(c *gin.Context) (*gorm.DB, error) {
+	query := database.DB.Model(&models.AuditEvent{}).Order("created_at DESC, id DESC")
+	if projectID := c.Query("project_id"); projectID != "" {
+		id, err := strconv.ParseUint(projectID, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("project_id = ?", id)
+	}
+	if actor := c.Query("actor"); actor != "" {
+		if id, err := strconv.ParseUint(actor, 10, 32); err == nil {
+			query = query.Where("actor_id = ?", id)
+		} else {
+			query = query.Where("actor = ?", actor)
+		}
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	for param, condition := range map[string]string{"since": "created_at >= ?", "until": "created_at < ?"} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where(condition, t)
+		}
+	}
+	return query, nil
+}
+
+// ListAuditEvents lists audit events, most recent first. With format=jsonl
+// every matching event is exported as JSON Lines; otherwise at most limit
+// events (default 100, max 1000) are returned.
+func ListAuditEvents(c *gin.Context) {
+	query, err := auditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter: " + err.Error()})
+		return
+	}
+
+	if c.Query("format") == "jsonl" {
+		exportAuditEvents(c, query)
+		return
+	}
+
+	limit := defaultAuditLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(limit, maxAuditLimit)
+	}
+
+	var events []models.AuditEvent
+	if err := query.Limit(limit).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list audit events"})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// exportAuditEvents streams the events matched by query as JSON Lines.
+func exportAuditEvents(c *gin.Context, query *gorm.DB) {
+	rows, err := query.Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export audit events"})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	for rows.Next() {
+		var event models.AuditEvent
+		if err := database.DB.ScanRows(rows, &event); err != nil {
+			log.Printf("Error reading audit event: %v", err)
+			return
+		}
+		if err := encoder.Encode(event); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/audit"
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogRecordsMutations(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.Use(audit.Middleware())
+	router.POST("/api/auth/setup", Setup)
+	router.POST("/api/auth/login", Login)
+	protected := router.Group("/api", auth.Required())
+	protected.POST("/projects", CreateProject)
+	protected.PUT("/environments/:id/variables/:varId", auth.RequireRole(models.RoleDeveloper, auth.EnvironmentScope("id")), UpdateEnvironmentVariable)
+	protected.GET("/audit", auth.AdminRequired(), ListAuditEvents)
+
+	w := doRequest(router, "POST", "/api/auth/setup", `{"username": "admin", "password": "correct horse"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	admin := sessionCookie(t, w)
+	w = doRequest(router, "POST", "/api/auth/login", `{"username": "admin", "password": "wrong password"}`)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(router, "POST", "/api/projects", `{"name": "shop"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	environment := models.Environment{Name: "dev", ProjectID: 1}
+	database.DB.Create(&environment)
+	variable := models.EnvironmentVariable{Key: "API_TOKEN", Value: "old", EnvironmentID: environment.ID}
+	database.DB.Create(&variable)
+	w = doRequest(router, "PUT", "/api/environments/1/variables/1", `{"key": "API_TOKEN", "value": "new"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(router, "GET", "/api/audit?project_id=1", "", admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	var events []models.AuditEvent
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	require.Len(t, events, 2)
+	assert.Equal(t, "variable.update", events[0].Action)
+	assert.Equal(t, "1", events[0].TargetID)
+	assert.Equal(t, map[string]interface{}{"from": audit.Redacted, "to": audit.Redacted}, events[0].Changes["value"])
+	assert.NotContains(t, events[0].Changes, "key")
+	assert.Equal(t, "project.create", events[1].Action)
+	assert.Equal(t, "admin", events[1].Actor)
+	assert.Equal(t, models.AuditSuccess, events[1].Result)
+
+	w = doRequest(router, "GET", "/api/audit?format=jsonl&action=auth.login", "", admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	var lines []models.AuditEvent
+	for scanner.Scan() {
+		var event models.AuditEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		lines = append(lines, event)
+	}
+	require.Len(t, lines, 1)
+	assert.Equal(t, models.AuditFailure, lines[0].Result)
+	assert.Equal(t, "Invalid username or password", lines[0].Error)
+	assert.Empty(t, lines[0].Changes)
+
+	w = doRequest(router, "GET", "/api/audit?since=yesterday", "", admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuditLogSkipsUnknownRoutesAndAnonymousPayloads(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.Use(audit.Middleware())
+	protected := router.Group("/api", auth.Required())
+	protected.POST("/projects", CreateProject)
+
+	w := doRequest(router, "POST", "/api/nowhere", `{"name": "spam"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest(router, "POST", "/api/projects", `{"name": "`+strings.Repeat("x", 1<<20)+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var events []models.AuditEvent
+	require.NoError(t, database.DB.Find(&events).Error)
+	require.Len(t, events, 1)
+	assert.Equal(t, "project.create", events[0].Action)
+	assert.Equal(t, models.AuditFailure, events[0].Result)
+	assert.Nil(t, events[0].ActorID)
+	assert.Empty(t, events[0].Changes)
+}
+
+func TestAuditLogPassesLargeBodiesThrough(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.Use(audit.Middleware())
+	router.POST("/api/auth/setup", Setup)
+	protected := router.Group("/api", auth.Required())
+	protected.POST("/projects", CreateProject)
+
+	w := doRequest(router, "POST", "/api/auth/setup", `{"username": "admin", "password": "correct horse"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	admin := sessionCookie(t, w)
+	description := strings.Repeat("x", 1<<20)
+	w = doRequest(router, "POST", "/api/projects", `{"name": "shop", "description": "`+description+`"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var project models.Project
+	require.NoError(t, database.DB.First(&project).Error)
+	assert.Equal(t, description, project.Description)
+	var event models.AuditEvent
+	require.NoError(t, database.DB.Where("action = ?", "project.create").First(&event).Error)
+	assert.Equal(t, "admin", event.Actor)
+	assert.Empty(t, event.Changes)
+}
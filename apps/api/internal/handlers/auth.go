@@ -0,0 +1,223 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// credentials is the request body for sign-in and user creation.
+type credentials struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+// errSetupDone is returned when the initial administrator already exists.
+var errSetupDone = errors.New("setup already completed")
+
+// SetupStatus reports whether the initial administrator still has to be created.
+func SetupStatus(c *gin.Context) {
+	var count int64
+	if err := database.DB.Model(&models.User{}).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check setup status"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"setup_required": count == 0})
+}
+
+// Setup creates the initial administrator. It is only allowed while no user exists.
+func Setup(c *gin.Context) {
+	var input credentials
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := models.User{Username: strings.TrimSpace(input.Username), PasswordHash: hash, IsAdmin: true}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errSetupDone
+		}
+		return tx.Create(&user).Error
+	})
+	if errors.Is(err, errSetupDone) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Setup has already been completed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	startSession(c, &user)
+}
+
+// Login signs a user in and sets the session cookie.
+func Login(c *gin.Context) {
+	var input credentials
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("username = ?", strings.TrimSpace(input.Username)).First(&user).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+	if !auth.CheckPassword(user.PasswordHash, input.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		return
+	}
+
+	startSession(c, &user)
+}
+
+// startSession creates a session for user and returns it as a cookie.
+func startSession(c *gin.Context, user *models.User) {
+	token, session, err := auth.CreateSession(user)
+	if err != nil {
+		log.Printf("Error creating session for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+	if err := auth.DeleteExpiredSessions(); err != nil {
+		log.Printf("Error deleting expired sessions: %v", err)
+	}
+
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(auth.SessionCookie, token, int(auth.SessionLifetime.Seconds()), "/", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{"user": user, "expires_at": session.ExpiresAt})
+}
+
+// Logout ends the current session and clears the session cookie.
+func Logout(c *gin.Context) {
+	if token, err := c.Cookie(auth.SessionCookie); err == nil {
+		if err := auth.DeleteSession(token); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign out"})
+			return
+		}
+	}
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(auth.SessionCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{"message": "Signed out"})
+}
+
+// GetCurrentUser returns the signed-in user.
+func GetCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, auth.CurrentUser(c))
+}
+
+// ListUsers lists all users.
+func ListUsers(c *gin.Context) {
+	var users []models.User
+	if err := database.DB.Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// CreateUser creates a new user.
+func CreateUser(c *gin.Context) {
+	var input credentials
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := models.User{Username: strings.TrimSpace(input.Username), PasswordHash: hash, IsAdmin: input.IsAdmin}
+	if err := database.DB.Create(&user).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to create user, the username may already be taken"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword changes the password of the signed-in user and ends their other sessions.
+func ChangePassword(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := auth.CurrentUser(c)
+	if !auth.CheckPassword(user.PasswordHash, input.CurrentPassword) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	hash, err := auth.HashPassword(input.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, _ := c.Cookie(auth.SessionCookie)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ? AND token_hash <> ?", user.ID, auth.HashToken(token)).Delete(&models.Session{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+// DeleteUser deletes a user and ends their sessions. Users cannot delete themselves.
+func DeleteUser(c *gin.Context) {
+	userID := c.Param("id")
+	if current := auth.CurrentUser(c); current != nil && userID == strconv.FormatUint(uint64(current.ID), 10) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot delete your own account"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.Session{}, &models.APIKey{}, &models.ProjectMember{}, &models.EnvironmentMember{}} {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// Delete permanently so the username can be reused.
+		return tx.Unscoped().Delete(&models.User{}, userID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
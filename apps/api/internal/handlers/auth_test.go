@@ -0,0 +1,111 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/auth"
+
+	"github.com/docker/docker/api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAuthRouter registers the authentication endpoints and a protected container listing.
+func setupAuthRouter(mockClient *MockDockerClient) *gin.Engine {
+	router := setupTestRouter(mockClient)
+	router.POST("/api/auth/setup", Setup)
+	router.POST("/api/auth/login", Login)
+	router.POST("/api/auth/logout", Logout)
+	protected := router.Group("", auth.Required())
+	protected.GET("/containers", ListContainers)
+	protected.POST("/api/users", auth.AdminRequired(), CreateUser)
+	return router
+}
+
+func doRequest(router *gin.Engine, method, path, body string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func sessionCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == auth.SessionCookie {
+			return cookie
+		}
+	}
+	require.Fail(t, "no session cookie set")
+	return nil
+}
+
+func TestProtectedRoutesRequireSession(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupAuthRouter(mockClient)
+
+	w := doRequest(router, "GET", "/containers", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(router, "GET", "/containers", "", &http.Cookie{Name: auth.SessionCookie, Value: "forged"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockClient.AssertNotCalled(t, "ContainerList", mock.Anything, mock.Anything)
+}
+
+func TestSetupLoginAndLogout(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupAuthRouter(mockClient)
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
+
+	w := doRequest(router, "POST", "/api/auth/setup", `{"username": "admin", "password": "correct horse"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Setup can only run once.
+	w = doRequest(router, "POST", "/api/auth/setup", `{"username": "other", "password": "correct horse"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(router, "POST", "/api/auth/login", `{"username": "admin", "password": "wrong password"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(router, "POST", "/api/auth/login", `{"username": "admin", "password": "correct horse"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	cookie := sessionCookie(t, w)
+	assert.True(t, cookie.HttpOnly)
+
+	w = doRequest(router, "GET", "/containers", "", cookie)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(router, "POST", "/api/auth/logout", "", cookie)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "GET", "/containers", "", cookie)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestOnlyAdminsCanCreateUsers(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupAuthRouter(mockClient)
+
+	w := doRequest(router, "POST", "/api/auth/setup", `{"username": "admin", "password": "correct horse"}`)
+	admin := sessionCookie(t, w)
+
+	w = doRequest(router, "POST", "/api/users", `{"username": "dev", "password": "battery staple"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "password")
+
+	w = doRequest(router, "POST", "/api/auth/login", `{"username": "dev", "password": "battery staple"}`)
+	developer := sessionCookie(t, w)
+	w = doRequest(router, "POST", "/api/users", `{"username": "eve", "password": "battery staple"}`, developer)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
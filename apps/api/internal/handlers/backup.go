@@ -0,0 +1,36 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"docker-manager/api/internal/backup"
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/database"
+	"github.com/gin-gonic/gin"
+)
+
+// BackupSettings selects where backups of the database are written and how
+// many are kept.
+var BackupSettings config.Backup
+
+// CreateBackup takes a snapshot of the database right away.
+func CreateBackup(c *gin.Context) {
+	created, err := backup.Create(database.DB, BackupSettings)
+	if errors.Is(err, backup.ErrUnsupported) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error backing up the database: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to back up the database: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
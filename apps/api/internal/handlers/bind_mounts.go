@@ -0,0 +1,57 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"docker-manager/api/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// AllowedBindPaths are the host directories users other than administrators
+// may bind mount into containers, with everything under them.
+var AllowedBindPaths []string
+
+// checkBindMounts responds with an error and returns false if the request may
+// not bind mount one of the host paths in sources. A bind mount of / or of
+// the Docker socket gives a container the whole host, so only administrators
+// may bind mount paths outside the allowed ones.
+func checkBindMounts(c *gin.Context, sources []string) bool {
+	if auth.IsAdmin(c) {
+		return true
+	}
+	for _, source := range sources {
+		if !bindAllowed(source) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can bind mount " + source + " into containers"})
+			return false
+		}
+	}
+	return true
+}
+
+// bindAllowed reports whether source is one of the allowed bind paths or
+// under one, once symbolic links are resolved.
+func bindAllowed(source string) bool {
+	source = resolvePath(source)
+	for _, dir := range AllowedBindPaths {
+		dir = resolvePath(dir)
+		if source == dir || strings.HasPrefix(source, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath cleans path and resolves the symbolic links in it, if it exists.
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
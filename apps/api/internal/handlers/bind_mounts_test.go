@@ -0,0 +1,66 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnlyAdministratorsBindMountOutsideTheAllowedPaths(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupRBACRouter(mockClient)
+	router.POST("/api/environments/:id/services", auth.Required(), auth.RequireRole(models.RoleDeveloper, auth.EnvironmentScope("id")), CreateService)
+	allowed := t.TempDir()
+	AllowedBindPaths = []string{allowed}
+	defer func() { AllowedBindPaths = nil }()
+
+	w := doRequest(router, "POST", "/api/auth/setup", `{"username": "admin", "password": "correct horse"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	admin := sessionCookie(t, w)
+	w = doRequest(router, "POST", "/api/users", `{"username": "dev", "password": "battery staple"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "POST", "/api/projects", `{"name": "shop"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "POST", "/api/projects/1/members", `{"user_id": 2, "role": "developer"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "POST", "/api/auth/login", `{"username": "dev", "password": "battery staple"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	developer := sessionCookie(t, w)
+	require.NoError(t, database.DB.Create(&models.Environment{Name: "dev", ProjectID: 1}).Error)
+
+	bind := func(source string) string {
+		return `{"name": "web", "type": "container", "image": "nginx", "spec": {"mounts": [{"type": "bind", "source": "` + source + `", "target": "/data"}]}}`
+	}
+	path := "/api/environments/1/services"
+	w = doRequest(router, "POST", path, bind("/var/run/docker.sock"), developer)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doRequest(router, "POST", path, bind(allowed+"/../.."), developer)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doRequest(router, "POST", path, bind(allowed+"/site"), developer)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doRequest(router, "POST", path, bind("/var/run/docker.sock"), admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Bind mounts of compose files are resolved against the file's directory.
+	stack := t.TempDir()
+	composePath := filepath.Join(stack, "compose.yaml")
+	require.NoError(t, os.WriteFile(composePath, []byte("services:\n  web:\n    image: nginx\n    volumes: [./html:/usr/share/nginx/html]\n"), 0o644))
+	w = doRequest(router, "POST", path, `{"name": "stack", "type": "compose", "compose_path": "`+composePath+`"}`, developer)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	AllowedBindPaths = append(AllowedBindPaths, stack)
+	w = doRequest(router, "POST", path, `{"name": "stack", "type": "compose", "compose_path": "`+composePath+`"}`, developer)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+)
+
+// specHashLabel is the container label holding the hash of the container
+// spec the container was created with. Containers of services without a
+// spec do not carry it.
+const specHashLabel = "dockman.spec-hash"
+
+// specHash identifies a container spec, "" for no spec.
+func specHash(spec *models.ContainerSpec) string {
+	if spec == nil {
+		return ""
+	}
+	// Maps are marshaled with sorted keys, so equal specs hash the same.
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// specEnv returns the KEY=value variables of a container: those of its
+// environment, overridden by those of its spec.
+func specEnv(env []string, spec *models.ContainerSpec) []string {
+	if spec == nil || len(spec.Env) == 0 {
+		return env
+	}
+	merged := make([]string, 0, len(env)+len(spec.Env))
+	for _, pair := range env {
+		key, _, _ := strings.Cut(pair, "=")
+		if _, ok := spec.Env[key]; !ok {
+			merged = append(merged, pair)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(spec.Env)) {
+		merged = append(merged, key+"="+spec.Env[key])
+	}
+	return merged
+}
+
+// containerConfig translates the spec of a container service into the
+// configuration of a container running image with the env variables of its
+// environment and the labels DockMan sets. A nil spec runs the image as is.
+func containerConfig(image string, spec *models.ContainerSpec, env []string, labels map[string]string) (*container.Config, *container.HostConfig, error) {
+	config := &container.Config{
+		Image:  image,
+		Env:    specEnv(env, spec),
+		Labels: labels,
+	}
+	hostConfig := &container.HostConfig{}
+	if spec == nil {
+		return config, hostConfig, nil
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid container spec: %w", err)
+	}
+
+	config.Cmd = spec.Command
+	config.Entrypoint = spec.Entrypoint
+	config.User = spec.User
+	if len(spec.Labels) > 0 {
+		config.Labels = maps.Clone(spec.Labels)
+		maps.Copy(config.Labels, labels)
+	}
+
+	if len(spec.Ports) > 0 {
+		config.ExposedPorts = nat.PortSet{}
+		hostConfig.PortBindings = nat.PortMap{}
+	}
+	for _, binding := range spec.Ports {
+		port, err := nat.NewPort(binding.Proto(), strconv.Itoa(binding.ContainerPort))
+		if err != nil {
+			return nil, nil, err
+		}
+		hostPort := ""
+		if binding.HostPort != 0 {
+			hostPort = strconv.Itoa(binding.HostPort)
+		}
+		config.ExposedPorts[port] = struct{}{}
+		hostConfig.PortBindings[port] = append(hostConfig.PortBindings[port], nat.PortBinding{HostIP: binding.HostIP, HostPort: hostPort})
+	}
+
+	for _, m := range spec.Mounts {
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:     mount.Type(m.Type),
+			Source:   m.Source,
+			Target:   m.Target,
+			ReadOnly: m.ReadOnly,
+		})
+	}
+
+	if spec.RestartPolicy != "" {
+		hostConfig.RestartPolicy = container.RestartPolicy{
+			Name:              container.RestartPolicyMode(spec.RestartPolicy),
+			MaximumRetryCount: spec.MaxRetries,
+		}
+	}
+	hostConfig.NanoCPUs = int64(spec.CPUs * 1e9)
+	memory, err := spec.MemoryBytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hostConfig.Memory = memory
+
+	if check := spec.Healthcheck; check != nil {
+		health := &container.HealthConfig{Test: check.Test, Retries: check.Retries}
+		// The durations were checked by Validate.
+		health.Interval, _ = parseOptionalDuration(check.Interval)
+		health.Timeout, _ = parseOptionalDuration(check.Timeout)
+		health.StartPeriod, _ = parseOptionalDuration(check.StartPeriod)
+		config.Healthcheck = health
+	}
+	return config, hostConfig, nil
+}
+
+// parseOptionalDuration parses a duration, 0 if it is empty.
+func parseOptionalDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// inspectedSpec reads back the spec of a container created outside of
+// DockMan: its published ports, mounts, restart policy and resource limits.
+// Its command, user, labels and healthcheck are left to its image, which
+// they usually come from.
+func inspectedSpec(info types.ContainerJSON) *models.ContainerSpec {
+	spec := &models.ContainerSpec{}
+	if hostConfig := info.HostConfig; hostConfig != nil {
+		ports := slices.SortedFunc(maps.Keys(hostConfig.PortBindings), func(a, b nat.Port) int {
+			if a.Int() != b.Int() {
+				return a.Int() - b.Int()
+			}
+			return strings.Compare(a.Proto(), b.Proto())
+		})
+		for _, port := range ports {
+			for _, binding := range hostConfig.PortBindings[port] {
+				hostPort, _ := strconv.Atoi(binding.HostPort)
+				hostIP := binding.HostIP
+				if hostIP == "0.0.0.0" || hostIP == "::" {
+					hostIP = ""
+				}
+				spec.Ports = append(spec.Ports, models.PortBinding{
+					HostIP:        hostIP,
+					HostPort:      hostPort,
+					ContainerPort: port.Int(),
+					Protocol:      port.Proto(),
+				})
+			}
+		}
+
+		if name := string(hostConfig.RestartPolicy.Name); name != "" && name != models.RestartNo {
+			spec.RestartPolicy = name
+			if name == models.RestartOnFailure {
+				spec.MaxRetries = hostConfig.RestartPolicy.MaximumRetryCount
+			}
+		}
+		spec.CPUs = float64(hostConfig.NanoCPUs) / 1e9
+		if hostConfig.Memory > 0 {
+			spec.Memory = memorySize(hostConfig.Memory)
+		}
+	}
+
+	for _, point := range info.Mounts {
+		m := models.Mount{Type: string(point.Type), Source: point.Source, Target: point.Destination, ReadOnly: !point.RW}
+		switch point.Type {
+		case mount.TypeVolume:
+			m.Source = point.Name
+		case mount.TypeBind:
+		default:
+			continue
+		}
+		spec.Mounts = append(spec.Mounts, m)
+	}
+	return spec
+}
+
+// memorySize formats a number of bytes the way a spec's memory limit is
+// written, in the largest unit that divides it, such as "512m".
+func memorySize(bytes int64) string {
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}} {
+		if bytes%unit.size == 0 {
+			return strconv.FormatInt(bytes/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateServiceValidatesSpec(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/environments/:id/services", CreateService)
+
+	w := doRequest(router, "POST", "/api/environments/1/services", `{"name": "web", "type": "container", "image": "nginx", "spec": {
+		"ports": [{"container_port": 0}, {"host_port": 8080, "container_port": 80}, {"host_port": 8080, "container_port": 81}],
+		"mounts": [{"type": "tmpfs", "source": "x", "target": "data"}],
+		"restart_policy": "sometimes", "memory": "lots",
+		"healthcheck": {"test": ["curl"], "interval": "often"},
+		"labels": {"dockman.service": "2"}
+	}}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var response struct {
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Invalid container spec: ports[0].container_port: must be between 1 and 65535; "+
+		"ports[2].host_port: 8080 is already published; "+
+		"mounts[0].type: must be bind or volume; mounts[0].target: must be an absolute path in the container; "+
+		"restart_policy: must be no, always, on-failure or unless-stopped; "+
+		"memory: must be a size such as 512m or 2g; "+
+		"healthcheck.test: must start with CMD, CMD-SHELL or NONE; "+
+		"healthcheck.interval: must be a duration of at least 1ms, such as 30s; "+
+		`labels: "dockman.service" is reserved, labels may not start with dockman.`, response.Error)
+
+	w = doRequest(router, "POST", "/api/environments/1/services", `{"name": "stack", "type": "compose", "compose_path": "/srv/compose.yml", "spec": {}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(router, "POST", "/api/environments/1/services", `{"name": "web", "type": "container", "image": "nginx", "spec": {
+		"ports": [{"host_port": 8080, "container_port": 80}], "memory": "256m", "restart_policy": "on-failure", "max_retries": 3
+	}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stored models.Service
+	require.NoError(t, database.DB.First(&stored).Error)
+	require.NotNil(t, stored.Spec)
+	assert.Equal(t, []models.PortBinding{{HostPort: 8080, ContainerPort: 80}}, stored.Spec.Ports)
+	assert.Equal(t, "256m", stored.Spec.Memory)
+}
+
+func TestUpServiceAppliesSpec(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/services/:id/up", UpService)
+
+	database.DB.Create(&models.EnvironmentVariable{Key: "LOG_LEVEL", Value: "info", EnvironmentID: 1})
+	database.DB.Create(&models.EnvironmentVariable{Key: "TZ", Value: "UTC", EnvironmentID: 1})
+	spec := &models.ContainerSpec{
+		Ports: []models.PortBinding{{HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 80}, {ContainerPort: 53, Protocol: "udp"}},
+		Mounts: []models.Mount{
+			{Type: models.MountVolume, Source: "webdata", Target: "/data"},
+			{Type: models.MountBind, Source: "/etc/web", Target: "/etc/web", ReadOnly: true},
+		},
+		Command:       []string{"serve", "--port", "80"},
+		Env:           map[string]string{"LOG_LEVEL": "debug"},
+		RestartPolicy: models.RestartOnFailure,
+		MaxRetries:    5,
+		CPUs:          1.5,
+		Memory:        "512m",
+		Healthcheck:   &models.Healthcheck{Test: []string{"CMD", "curl", "-f", "http://localhost"}, Interval: "30s", Retries: 3},
+		Labels:        map[string]string{"team": "web"},
+		User:          "1000:1000",
+	}
+	service := models.Service{Name: "web", Type: "container", Image: "web:1", EnvironmentID: 1, Spec: spec}
+	require.NoError(t, database.DB.Create(&service).Error)
+
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "web:1").Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(config *container.Config) bool {
+		return assert.ObjectsAreEqual([]string{"TZ=UTC", "LOG_LEVEL=debug"}, config.Env) &&
+			assert.ObjectsAreEqual([]string{"serve", "--port", "80"}, []string(config.Cmd)) &&
+			config.User == "1000:1000" &&
+			config.Labels["team"] == "web" && config.Labels[serviceLabel] == "1" && config.Labels[specHashLabel] == specHash(spec) &&
+			assert.ObjectsAreEqual(nat.PortSet{"80/tcp": {}, "53/udp": {}}, config.ExposedPorts) &&
+			assert.ObjectsAreEqual(&container.HealthConfig{Test: []string{"CMD", "curl", "-f", "http://localhost"}, Interval: 30 * time.Second, Retries: 3}, config.Healthcheck)
+	}), mock.MatchedBy(func(hostConfig *container.HostConfig) bool {
+		return assert.ObjectsAreEqual(nat.PortMap{
+			"80/tcp": {{HostIP: "127.0.0.1", HostPort: "8080"}},
+			"53/udp": {{}},
+		}, hostConfig.PortBindings) &&
+			assert.ObjectsAreEqual([]mount.Mount{
+				{Type: mount.TypeVolume, Source: "webdata", Target: "/data"},
+				{Type: mount.TypeBind, Source: "/etc/web", Target: "/etc/web", ReadOnly: true},
+			}, hostConfig.Mounts) &&
+			hostConfig.RestartPolicy == container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 5} &&
+			hostConfig.NanoCPUs == 1_500_000_000 && hostConfig.Memory == 512<<20
+	}), mock.Anything, mock.Anything, mock.Anything).Return(container.CreateResponse{ID: "web-container"}, nil)
+	mockClient.On("ContainerStart", mock.Anything, "web-container", mock.Anything).Return(nil)
+
+	job := waitForJob(t, doRequest(router, "POST", "/api/services/1/up", ""))
+	require.Equal(t, models.JobSucceeded, job.Status, job.Error)
+	var deployment models.Deployment
+	require.NoError(t, database.DB.First(&deployment).Error)
+	assert.Equal(t, spec, deployment.Spec)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpServiceRecreatesContainerWhenSpecChanges(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/services/:id/up", UpService)
+
+	service := models.Service{Name: "web", Type: "container", Image: "web:1", EnvironmentID: 1, ContainerID: "old",
+		Spec: &models.ContainerSpec{Memory: "1g"}}
+	require.NoError(t, database.DB.Create(&service).Error)
+	labels, err := ownershipLabels(&service)
+	require.NoError(t, err)
+	labels[envHashLabel] = mustEnvironmentHash(nil)
+	labels[specHashLabel] = specHash(&models.ContainerSpec{Memory: "512m"})
+
+	mockClient.On("ContainerInspect", mock.Anything, "old").Return(types.ContainerJSON{
+		Config: &container.Config{Image: "web:1", Labels: labels},
+	}, nil)
+	mockClient.On("ContainerRemove", mock.Anything, "old", mock.Anything).Return(nil)
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "web:1").Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.MatchedBy(func(hostConfig *container.HostConfig) bool {
+		return hostConfig.Memory == 1<<30
+	}), mock.Anything, mock.Anything, mock.Anything).Return(container.CreateResponse{ID: "new"}, nil)
+	mockClient.On("ContainerStart", mock.Anything, "new", mock.Anything).Return(nil)
+
+	job := waitForJob(t, doRequest(router, "POST", "/api/services/1/up", ""))
+	require.Equal(t, models.JobSucceeded, job.Status, job.Error)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/compose"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/jobs"
+	"docker-manager/api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDeployments lists the deployments of a service, most recent first.
+func ListDeployments(c *gin.Context) {
+	var deployments []models.Deployment
+	if err := database.DB.Where("service_id = ?", c.Param("id")).Order("id DESC").Find(&deployments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list deployments"})
+		return
+	}
+	c.JSON(http.StatusOK, deployments)
+}
+
+// RollbackService re-applies the snapshot of a previous deployment of a
+// service: the same image digests, compose file, variables and replicas.
+func RollbackService(c *gin.Context) {
+	var service models.Service
+	if err := database.DB.First(&service, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	var target models.Deployment
+	if err := database.DB.Where("service_id = ?", service.ID).First(&target, c.Param("deploymentId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deployment not found"})
+		return
+	}
+	if target.Status != models.DeploymentSucceeded {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only successful deployments can be rolled back to"})
+		return
+	}
+	env, err := target.Environment()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decrypt the deployment's variables"})
+		return
+	}
+
+	deployment := newDeployment(c, &service, models.DeploymentRollback)
+	deployment.RollbackOfID = &target.ID
+	switch service.Type {
+	case "container":
+		image := target.ImageDigest
+		if image == "" {
+			image = target.Image
+		}
+		runDeployment(c, &service, deployment, func(ctx context.Context, out io.Writer) error {
+			return deployContainer(ctx, &service, deployment, image, target.Spec, env, out)
+		})
+	case "compose":
+		runDeployment(c, &service, deployment, func(ctx context.Context, out io.Writer) error {
+			return rollbackCompose(ctx, &service, &target, deployment, env, out)
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot roll back a %s service", service.Type)})
+	}
+}
+
+// rollbackCompose re-applies the compose file, image digests and replicas of target.
+func rollbackCompose(ctx context.Context, service *models.Service, target, deployment *models.Deployment, env []string, out io.Writer) error {
+	file, err := compose.Parse([]byte(target.ComposeFile), env)
+	if err != nil {
+		return fmt.Errorf("parse compose file snapshot: %w", err)
+	}
+	if err := syncComposeSubServices(database.DB, service, file); err != nil {
+		return fmt.Errorf("update sub-services: %w", err)
+	}
+	for name, digest := range target.ImageDigests {
+		if config, ok := file.Services[name]; ok && digest != "" {
+			config.Image = digest
+			file.Services[name] = config
+		}
+	}
+
+	deployment.ComposeFile = target.ComposeFile
+	hash, err := environmentHash(env)
+	if err != nil {
+		return err
+	}
+	if err := deployment.SetEnvironment(env, hash); err != nil {
+		return err
+	}
+	project := &compose.Project{
+		Name:       composeProjectName(service),
+		WorkingDir: filepath.Dir(service.ComposePath),
+		File:       file,
+	}
+
+	engine, err := composeEngine(ctx, service)
+	if err != nil {
+		return err
+	}
+	if err := engine.Up(ctx, project, out); err != nil {
+		return err
+	}
+	current, err := engine.Replicas(ctx, project.Name)
+	if err != nil {
+		return err
+	}
+	for _, name := range file.ServiceNames() {
+		if replicas, ok := target.Replicas[name]; ok && replicas != current[name] {
+			if err := engine.Scale(ctx, project, name, replicas, out); err != nil {
+				return err
+			}
+		}
+	}
+	return recordComposeState(ctx, project, deployment)
+}
+
+// newDeployment prepares the record of a deployment of a service triggered
+// by the current user. It is saved by runDeployment.
+func newDeployment(c *gin.Context, service *models.Service, kind string) *models.Deployment {
+	deployment := &models.Deployment{
+		ServiceID: service.ID,
+		Kind:      kind,
+		Status:    models.DeploymentRunning,
+	}
+	if user := auth.CurrentUser(c); user != nil {
+		deployment.TriggeredByID = &user.ID
+		deployment.TriggeredBy = user.Username
+	}
+	return deployment
+}
+
+// runDeployment saves a deployment and runs deploy in a background job,
+// responding with the job clients can follow on /ws/jobs/:id. Only one
+// deployment of a service runs at a time.
+func runDeployment(c *gin.Context, service *models.Service, deployment *models.Deployment, deploy jobs.Func) {
+	job, err := startDeployment(service, deployment, deploy)
+	if err != nil {
+		if errors.Is(err, jobs.ErrBusy) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another deployment of this service is in progress"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start deployment"})
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Deployment started", "job": job, "deployment_id": deployment.ID})
+}
+
+// startDeployment saves a deployment and runs deploy in a background job. It
+// returns jobs.ErrBusy while another deployment of the service is running.
+func startDeployment(service *models.Service, deployment *models.Deployment, deploy jobs.Func) (*models.Job, error) {
+	deployment.StartedAt = time.Now()
+	if err := database.DB.Create(deployment).Error; err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		Kind:         "deploy",
+		ServiceID:    &service.ID,
+		DeploymentID: &deployment.ID,
+		CreatedByID:  deployment.TriggeredByID,
+		CreatedBy:    deployment.TriggeredBy,
+	}
+	err := jobs.Start(job, fmt.Sprintf("service:%d", service.ID), func(ctx context.Context, out io.Writer) error {
+		var output bytes.Buffer
+		err := deploy(ctx, io.MultiWriter(out, &output))
+		finishDeployment(ctx, deployment, &output, err)
+		return err
+	})
+	if err != nil {
+		// Nothing was deployed, so the deployment is not kept in the history.
+		database.DB.Unscoped().Delete(deployment)
+		return nil, err
+	}
+	return job, nil
+}
+
+// finishDeployment records the outcome and output of a deployment.
+func finishDeployment(ctx context.Context, deployment *models.Deployment, output *bytes.Buffer, err error) {
+	now := time.Now()
+	deployment.FinishedAt = &now
+	deployment.Output = output.String()
+	switch {
+	case ctx.Err() != nil:
+		deployment.Status = models.DeploymentCanceled
+		deployment.Error = "canceled"
+	case err != nil:
+		deployment.Status = models.DeploymentFailed
+		deployment.Error = err.Error()
+	default:
+		deployment.Status = models.DeploymentSucceeded
+	}
+	if err := database.DB.Save(deployment).Error; err != nil {
+		log.Printf("Error saving deployment %d: %v", deployment.ID, err)
+	}
+}
+
+// deployContainer runs the container of a container service from image and
+// spec with the env variables, and records them on deployment.
+func deployContainer(ctx context.Context, service *models.Service, deployment *models.Deployment, image string, spec *models.ContainerSpec, env []string, out io.Writer) error {
+	deployment.Image = image
+	deployment.Spec = spec
+	hash, err := environmentHash(env)
+	if err != nil {
+		return err
+	}
+	if err := deployment.SetEnvironment(env, hash); err != nil {
+		return err
+	}
+	if err := startContainerService(ctx, service, image, spec, env); err != nil {
+		return err
+	}
+	digest, err := imageDigest(ctx, image)
+	if err != nil {
+		return fmt.Errorf("resolve image digest: %w", err)
+	}
+	deployment.ImageDigest = digest
+	fmt.Fprintf(out, "Container %s (%s) running %s\n", containerName(service), service.ContainerID, image)
+	return nil
+}
+
+// snapshotComposeProject loads the compose file of a compose service and
+// records its contents and the variables it was interpolated with on deployment.
+func snapshotComposeProject(service *models.Service, deployment *models.Deployment) (*compose.Project, error) {
+	env, err := environmentVariableList(service.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(service.ComposePath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := compose.Parse(data, env)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment.ComposeFile = string(data)
+	hash, err := environmentHash(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := deployment.SetEnvironment(env, hash); err != nil {
+		return nil, err
+	}
+	return &compose.Project{
+		Name:       composeProjectName(service),
+		WorkingDir: filepath.Dir(service.ComposePath),
+		File:       file,
+	}, nil
+}
+
+// recordComposeState records the image digest and number of replicas of every
+// service of a compose project on deployment.
+func recordComposeState(ctx context.Context, project *compose.Project, deployment *models.Deployment) error {
+	replicas, err := compose.NewEngine(DockerClient).Replicas(ctx, project.Name)
+	if err != nil {
+		return err
+	}
+	deployment.Replicas = map[string]int{}
+	deployment.ImageDigests = map[string]string{}
+	for _, name := range project.File.ServiceNames() {
+		deployment.Replicas[name] = replicas[name]
+		digest, err := imageDigest(ctx, project.File.Services[name].Image)
+		if err != nil {
+			return fmt.Errorf("resolve image digest of %s: %w", name, err)
+		}
+		deployment.ImageDigests[name] = digest
+	}
+	return nil
+}
+
+// imageDigest returns a reference that pins image to its current content: its
+// repository digest when it was pulled from a registry, its ID otherwise.
+func imageDigest(ctx context.Context, image string) (string, error) {
+	inspect, _, err := DockerClient.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	repository := image
+	if at := strings.Index(repository, "@"); at >= 0 {
+		repository = repository[:at]
+	} else if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		repository = repository[:colon]
+	}
+	for _, digest := range inspect.RepoDigests {
+		if strings.HasPrefix(digest, repository+"@") {
+			return digest, nil
+		}
+	}
+	return inspect.ID, nil
+}
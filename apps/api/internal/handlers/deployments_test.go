@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackReappliesImageDigestAndVariables(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/services/:id/up", UpService)
+	router.POST("/api/services/:id/rollback/:deploymentId", RollbackService)
+
+	variable := models.EnvironmentVariable{Key: "RELEASE", Value: "1", EnvironmentID: 1}
+	database.DB.Create(&variable)
+	service := models.Service{Name: "api", Type: "container", Image: "example/api:latest", EnvironmentID: 1}
+	database.DB.Create(&service)
+
+	// First deployment runs the image that is then tagged latest.
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "example/api:latest").Return(types.ImageInspect{
+		ID: "sha256:first", RepoDigests: []string{"example/api@sha256:aaa"},
+	}, []byte{}, nil).Twice()
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(container.CreateResponse{ID: "first"}, nil).Once()
+	mockClient.On("ContainerStart", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/services/%d/up", service.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, models.JobSucceeded, waitForJob(t, w).Status)
+
+	var first models.Deployment
+	require.NoError(t, database.DB.First(&first).Error)
+	assert.Equal(t, models.DeploymentSucceeded, first.Status)
+	assert.Equal(t, "example/api@sha256:aaa", first.ImageDigest)
+	assert.True(t, environmentMatches([]string{"RELEASE=1"}, first.EnvHash))
+	assert.False(t, environmentMatches([]string{"RELEASE=2"}, first.EnvHash))
+	assert.NotContains(t, first.EnvSnapshot, "RELEASE")
+
+	// Variables change afterwards; the rollback still uses the snapshot.
+	variable.Value = "2"
+	database.DB.Save(&variable)
+	database.DB.Model(&service).Update("container_id", "")
+
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "example/api@sha256:aaa").Return(types.ImageInspect{
+		ID: "sha256:first", RepoDigests: []string{"example/api@sha256:aaa"},
+	}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(config *container.Config) bool {
+		return config.Image == "example/api@sha256:aaa" && assert.ObjectsAreEqual([]string{"RELEASE=1"}, config.Env)
+	}), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(container.CreateResponse{ID: "rolled-back"}, nil).Once()
+
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/services/%d/rollback/%d", service.ID, first.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, models.JobSucceeded, waitForJob(t, w).Status)
+
+	var rollback models.Deployment
+	require.NoError(t, database.DB.Last(&rollback).Error)
+	assert.Equal(t, models.DeploymentRollback, rollback.Kind)
+	assert.Equal(t, first.ID, *rollback.RollbackOfID)
+	assert.Equal(t, first.EnvHash, rollback.EnvHash)
+	mockClient.AssertExpectations(t)
+}
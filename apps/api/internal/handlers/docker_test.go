@@ -7,8 +7,10 @@ package handlers
 
 import (
 	"context"
+	"docker-manager/api/internal/crypto/cryptotest"
 	"docker-manager/api/internal/database"
-	"docker-manager/api/internal/models"
+	"docker-manager/api/internal/database/databasetest"
+	"docker-manager/api/internal/migrations"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -17,13 +19,14 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 // MockDockerClient is a mock implementation of the Docker client for testing.
@@ -36,15 +39,20 @@ type MockDockerClient struct {
 func setupTestRouter(mockClient *MockDockerClient) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 
-	// Setup in-memory SQLite for testing
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	// Setup in-memory SQLite, or PostgreSQL, for testing
+	db, err := databasetest.Open()
 	if err != nil {
 		panic("Failed to connect to database: " + err.Error())
 	}
 	database.DB = db
+	if err := cryptotest.Init(); err != nil {
+		panic("Failed to set up encryption keys: " + err.Error())
+	}
 
-	// Migrate the schema for the test database
-	db.AutoMigrate(&models.Project{}, &models.Environment{}, &models.Service{})
+	// Migrate the schema for the test database as the server does
+	if _, err := migrations.Up(db); err != nil {
+		panic("Failed to migrate database: " + err.Error())
+	}
 
 	router := gin.Default()
 
@@ -147,6 +155,11 @@ func (m *MockDockerClient) ImageInspectWithRaw(ctx context.Context, imageID stri
 	return args.Get(0).(types.ImageInspect), args.Get(1).([]byte), args.Error(2)
 }
 
+func (m *MockDockerClient) RegistryLogin(ctx context.Context, auth registry.AuthConfig) (registry.AuthenticateOKBody, error) {
+	args := m.Called(ctx, auth)
+	return args.Get(0).(registry.AuthenticateOKBody), args.Error(1)
+}
+
 func (m *MockDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.CreateResponse, error) {
 	args := m.Called(ctx, config, hostConfig, networkingConfig, platform, containerName)
 	return args.Get(0).(container.CreateResponse), args.Error(1)
@@ -181,3 +194,73 @@ func (m *MockDockerClient) ContainerStats(ctx context.Context, containerID strin
 	args := m.Called(ctx, containerID, stream)
 	return args.Get(0).(types.ContainerStats), args.Error(1)
 }
+
+func (m *MockDockerClient) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]types.NetworkResource), args.Error(1)
+}
+
+func (m *MockDockerClient) NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
+	args := m.Called(ctx, name, options)
+	return args.Get(0).(types.NetworkCreateResponse), args.Error(1)
+}
+
+func (m *MockDockerClient) NetworkInspect(ctx context.Context, networkID string, options types.NetworkInspectOptions) (types.NetworkResource, error) {
+	args := m.Called(ctx, networkID, options)
+	return args.Get(0).(types.NetworkResource), args.Error(1)
+}
+
+func (m *MockDockerClient) NetworkDisconnect(ctx context.Context, networkID, containerID string, force bool) error {
+	args := m.Called(ctx, networkID, containerID, force)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error {
+	args := m.Called(ctx, networkID, containerID, config)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) NetworkRemove(ctx context.Context, networkID string) error {
+	args := m.Called(ctx, networkID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).(volume.ListResponse), args.Error(1)
+}
+
+func (m *MockDockerClient) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).(volume.Volume), args.Error(1)
+}
+
+func (m *MockDockerClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	args := m.Called(ctx, volumeID, force)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error) {
+	args := m.Called(ctx, volumeID)
+	return args.Get(0).(volume.Volume), args.Error(1)
+}
+
+func (m *MockDockerClient) DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).(types.DiskUsage), args.Error(1)
+}
+
+func (m *MockDockerClient) Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).(<-chan events.Message), args.Get(1).(<-chan error)
+}
+
+func (m *MockDockerClient) ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error) {
+	args := m.Called(ctx, containerID, condition)
+	return args.Get(0).(<-chan container.WaitResponse), args.Get(1).(<-chan error)
+}
+
+func (m *MockDockerClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error) {
+	args := m.Called(ctx, containerID, srcPath)
+	return args.Get(0).(io.ReadCloser), args.Get(1).(types.ContainerPathStat), args.Error(2)
+}
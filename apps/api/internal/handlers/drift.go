@@ -0,0 +1,348 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/compose"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Kinds of drift between the services recorded in the database and the
+// containers on the Docker host.
+const (
+	// The container of a deployed service is gone.
+	driftMissing = "missing"
+	// A container labelled as DockMan's no longer belongs to any service.
+	driftOrphaned = "orphaned"
+	// A container runs another image than its service's.
+	driftImageMismatch = "image_mismatch"
+	// A container was created with other variables, another spec or without
+	// ownership labels.
+	driftConfigMismatch = "config_mismatch"
+)
+
+// drift is a difference between a service and its containers.
+type drift struct {
+	Kind          string `json:"kind"`
+	ServiceID     uint   `json:"service_id,omitempty"`
+	ServiceName   string `json:"service_name,omitempty"`
+	EnvironmentID uint   `json:"environment_id,omitempty"`
+	ContainerID   string `json:"container_id,omitempty"`
+	ContainerName string `json:"container_name,omitempty"`
+	Expected      string `json:"expected,omitempty"`
+	Actual        string `json:"actual,omitempty"`
+	Detail        string `json:"detail"`
+
+	// redeploy is the service to deploy again to heal the drift, the parent
+	// compose service for sub-services.
+	redeploy uint
+}
+
+// ownershipLabels returns the labels marking the containers of a service as
+// owned by it. The containers of a compose stack carry the compose service,
+// and the sub-service they run is named by compose.ServiceLabel.
+func ownershipLabels(service *models.Service) (map[string]string, error) {
+	labels := map[string]string{
+		serviceLabel:     strconv.FormatUint(uint64(service.ID), 10),
+		environmentLabel: strconv.FormatUint(uint64(service.EnvironmentID), 10),
+	}
+	scope, err := auth.ScopeOfEnvironment(service.EnvironmentID)
+	switch {
+	case err == nil:
+		labels[projectLabel] = strconv.FormatUint(uint64(scope.ProjectID), 10)
+	case !errors.Is(err, auth.ErrScopeNotFound):
+		return nil, err
+	}
+	return labels, nil
+}
+
+// hasLabels reports whether labels contain every label in want.
+func hasLabels(labels, want map[string]string) bool {
+	for key, value := range want {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// lastDeployment returns the latest successful deployment of a service, or
+// an empty deployment if there is none.
+func lastDeployment(serviceID uint) (models.Deployment, error) {
+	var deployment models.Deployment
+	err := database.DB.Omit("output").
+		Where("service_id = ? AND status = ?", serviceID, models.DeploymentSucceeded).
+		Order("id DESC").First(&deployment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	return deployment, err
+}
+
+// newDrift returns a drift of a service.
+func newDrift(kind string, service *models.Service, redeploy uint, detail string) drift {
+	return drift{
+		Kind:          kind,
+		ServiceID:     service.ID,
+		ServiceName:   service.Name,
+		EnvironmentID: service.EnvironmentID,
+		Detail:        detail,
+		redeploy:      redeploy,
+	}
+}
+
+// withContainer sets the container a drift is about.
+func (d drift) withContainer(ctr types.Container) drift {
+	d.ContainerID = ctr.ID
+	if len(ctr.Names) > 0 {
+		d.ContainerName = strings.TrimPrefix(ctr.Names[0], "/")
+	}
+	return d
+}
+
+// detectDrift compares the services in the database with the containers on
+// the Docker host. Container services are matched by the container ID they
+// record and compose stacks by their project name; any other container
+// labelled with a service is an orphan. A compose stack without any
+// container is considered down rather than missing.
+func detectDrift(ctx context.Context) ([]drift, error) {
+	containers, err := DockerClient.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	var services []models.Service
+	if err := database.DB.Order("id").Find(&services).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]*models.Service, len(services))
+	subServices := map[uint]map[string]*models.Service{}
+	stacks := map[string]*models.Service{}
+	for i := range services {
+		service := &services[i]
+		byID[service.ID] = service
+		if service.ParentServiceID != nil {
+			if subServices[*service.ParentServiceID] == nil {
+				subServices[*service.ParentServiceID] = map[string]*models.Service{}
+			}
+			subServices[*service.ParentServiceID][service.Name] = service
+		}
+		if service.Type == "compose" {
+			stacks[composeProjectName(service)] = service
+		}
+	}
+	byContainer := make(map[string]types.Container, len(containers))
+	stackContainers := map[uint][]types.Container{}
+	for _, ctr := range containers {
+		byContainer[ctr.ID] = ctr
+		if parent := stacks[ctr.Labels[compose.ProjectLabel]]; parent != nil {
+			stackContainers[parent.ID] = append(stackContainers[parent.ID], ctr)
+		}
+	}
+
+	report := []drift{}
+	claimed := map[string]bool{}
+	for i := range services {
+		service := &services[i]
+		switch {
+		case service.Type == "container" && service.ParentServiceID == nil && service.ContainerID != "":
+			ctr, ok := byContainer[service.ContainerID]
+			if !ok {
+				report = append(report, newDrift(driftMissing, service, service.ID, "The container of the service no longer exists"))
+				continue
+			}
+			claimed[ctr.ID] = true
+			found, err := containerDrift(service, ctr)
+			if err != nil {
+				return nil, err
+			}
+			report = append(report, found...)
+		case service.Type == "compose" && len(stackContainers[service.ID]) > 0:
+			found, err := stackDrift(service, subServices[service.ID], stackContainers[service.ID])
+			if err != nil {
+				return nil, err
+			}
+			for _, ctr := range stackContainers[service.ID] {
+				claimed[ctr.ID] = true
+			}
+			report = append(report, found...)
+		}
+	}
+
+	for _, ctr := range containers {
+		id := ctr.Labels[serviceLabel]
+		if claimed[ctr.ID] || id == "" {
+			continue
+		}
+		orphan := drift{Kind: driftOrphaned, Detail: "The service that created the container was deleted"}
+		if serviceID, err := strconv.ParseUint(id, 10, 32); err == nil && byID[uint(serviceID)] != nil {
+			service := byID[uint(serviceID)]
+			orphan = newDrift(driftOrphaned, service, 0, "The service no longer uses the container")
+		}
+		report = append(report, orphan.withContainer(ctr))
+	}
+	return report, nil
+}
+
+// containerDrift compares a container service with its container. The image,
+// spec and variables of its latest successful deployment, such as a rollback,
+// are expected as well as its current ones.
+func containerDrift(service *models.Service, ctr types.Container) ([]drift, error) {
+	last, err := lastDeployment(service.ID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := ownershipLabels(service)
+	if err != nil {
+		return nil, err
+	}
+	env, err := environmentVariableList(service.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []drift
+	if ctr.Image != service.Image && ctr.Image != last.Image && (last.ImageDigest == "" || ctr.Image != last.ImageDigest) {
+		d := newDrift(driftImageMismatch, service, service.ID, "The container runs another image than the service").withContainer(ctr)
+		d.Expected, d.Actual = service.Image, ctr.Image
+		found = append(found, d)
+	}
+	envHash, spec := ctr.Labels[envHashLabel], ctr.Labels[specHashLabel]
+	switch {
+	case !hasLabels(ctr.Labels, labels):
+		found = append(found, newDrift(driftConfigMismatch, service, service.ID, "The container is missing the labels recording its owner").withContainer(ctr))
+	case !environmentMatches(env, envHash) && envHash != last.EnvHash:
+		found = append(found, newDrift(driftConfigMismatch, service, service.ID, "The environment variables changed since the container was created").withContainer(ctr))
+	case spec != specHash(service.Spec) && spec != specHash(last.Spec):
+		found = append(found, newDrift(driftConfigMismatch, service, service.ID, "The container spec changed since the container was created").withContainer(ctr))
+	}
+	return found, nil
+}
+
+// stackDrift compares the sub-services of a compose service with the
+// containers of its stack.
+func stackDrift(parent *models.Service, subServices map[string]*models.Service, containers []types.Container) ([]drift, error) {
+	last, err := lastDeployment(parent.ID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := ownershipLabels(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []drift
+	running := map[string]bool{}
+	for _, ctr := range containers {
+		name := ctr.Labels[compose.ServiceLabel]
+		subService := subServices[name]
+		if subService == nil {
+			found = append(found, newDrift(driftOrphaned, parent, 0, "The compose file no longer declares "+name).withContainer(ctr))
+			continue
+		}
+		running[name] = true
+		if digest := last.ImageDigests[name]; ctr.Image != subService.Image && (digest == "" || ctr.Image != digest) {
+			d := newDrift(driftImageMismatch, subService, parent.ID, "The container runs another image than the service").withContainer(ctr)
+			d.Expected, d.Actual = subService.Image, ctr.Image
+			found = append(found, d)
+		}
+		if !hasLabels(ctr.Labels, labels) {
+			found = append(found, newDrift(driftConfigMismatch, subService, parent.ID, "The container is missing the labels recording its owner").withContainer(ctr))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(subServices)) {
+		if !running[name] {
+			found = append(found, newDrift(driftMissing, subServices[name], parent.ID, "The stack has no container for this service"))
+		}
+	}
+	return found, nil
+}
+
+// GetDrift reports the differences between the services in the database and
+// the containers on the Docker host.
+func GetDrift(c *gin.Context) {
+	report, err := detectDrift(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect drift: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"drift": report})
+}
+
+// HealDrift fixes the drift between services and containers: orphaned
+// containers are removed and the services whose containers are missing or
+// out of date are deployed again, each in its own background job.
+func HealDrift(c *gin.Context) {
+	ctx := c.Request.Context()
+	report, err := detectDrift(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect drift: " + err.Error()})
+		return
+	}
+
+	type failure struct {
+		ServiceID   uint   `json:"service_id,omitempty"`
+		ContainerID string `json:"container_id,omitempty"`
+		Error       string `json:"error"`
+	}
+	removed := []string{}
+	started := []*models.Job{}
+	failures := []failure{}
+	var redeploy []uint
+	seen := map[uint]bool{}
+	for _, d := range report {
+		if d.Kind == driftOrphaned {
+			if err := DockerClient.ContainerRemove(ctx, d.ContainerID, container.RemoveOptions{Force: true}); err != nil && !client.IsErrNotFound(err) {
+				failures = append(failures, failure{ContainerID: d.ContainerID, Error: err.Error()})
+				continue
+			}
+			removed = append(removed, d.ContainerID)
+			continue
+		}
+		if !seen[d.redeploy] {
+			seen[d.redeploy] = true
+			redeploy = append(redeploy, d.redeploy)
+		}
+	}
+
+	for _, id := range redeploy {
+		var service models.Service
+		if err := database.DB.First(&service, id).Error; err != nil {
+			failures = append(failures, failure{ServiceID: id, Error: "Service not found"})
+			continue
+		}
+		deployment := newDeployment(c, &service, models.DeploymentHeal)
+		deploy, err := upDeployment(&service, deployment)
+		if err != nil {
+			failures = append(failures, failure{ServiceID: id, Error: err.Error()})
+			continue
+		}
+		job, err := startDeployment(&service, deployment, deploy)
+		if err != nil {
+			failures = append(failures, failure{ServiceID: id, Error: err.Error()})
+			continue
+		}
+		started = append(started, job)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"drift": report, "removed": removed, "jobs": started, "errors": failures})
+}
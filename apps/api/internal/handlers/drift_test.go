@@ -0,0 +1,117 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/jobs"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// owned returns the labels of a container deployed for the service with the
+// given ID, in an environment without variables.
+func owned(serviceID string) map[string]string {
+	return map[string]string{serviceLabel: serviceID, environmentLabel: "1", envHashLabel: mustEnvironmentHash(nil)}
+}
+
+// mustEnvironmentHash returns the hash of env, which tests expect to succeed.
+func mustEnvironmentHash(env []string) string {
+	hash, err := environmentHash(env)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+func TestGetDriftReportsEveryKind(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.GET("/api/drift", GetDrift)
+	require.NoError(t, database.DB.Create(&[]models.Service{
+		{Name: "in-sync", Type: "container", Image: "nginx:1", ContainerID: "web", EnvironmentID: 1},
+		{Name: "gone", Type: "container", Image: "redis:7", ContainerID: "redis", EnvironmentID: 1},
+		{Name: "stale", Type: "container", Image: "api:2", ContainerID: "api", EnvironmentID: 1},
+	}).Error)
+
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		{ID: "web", Names: []string{"/dockman-1-in-sync"}, Image: "nginx:1", Labels: owned("1")},
+		{ID: "api", Names: []string{"/dockman-3-stale"}, Image: "api:1"},
+		{ID: "web-old", Image: "nginx:0", Labels: owned("1")},
+		{ID: "deleted", Image: "nginx:1", Labels: owned("42")},
+		{ID: "unmanaged", Image: "postgres:16"},
+	}, nil)
+
+	w := doRequest(router, "GET", "/api/drift", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Drift []drift `json:"drift"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Drift, 5)
+
+	kinds := make([]string, len(response.Drift))
+	for i, d := range response.Drift {
+		kinds[i] = d.Kind
+	}
+	assert.Equal(t, []string{driftMissing, driftImageMismatch, driftConfigMismatch, driftOrphaned, driftOrphaned}, kinds)
+	assert.Equal(t, uint(2), response.Drift[0].ServiceID)
+	assert.Equal(t, "api:2", response.Drift[1].Expected)
+	assert.Equal(t, "api:1", response.Drift[1].Actual)
+	assert.Equal(t, "dockman-3-stale", response.Drift[2].ContainerName)
+	assert.Equal(t, uint(1), response.Drift[3].ServiceID)
+	assert.Equal(t, "web-old", response.Drift[3].ContainerID)
+	assert.Zero(t, response.Drift[4].ServiceID)
+	assert.Equal(t, "deleted", response.Drift[4].ContainerID)
+}
+
+func TestHealDriftRedeploysAndRemovesOrphans(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/drift/heal", HealDrift)
+	service := models.Service{Name: "gone", Type: "container", Image: "redis:7", ContainerID: "redis", EnvironmentID: 1}
+	require.NoError(t, database.DB.Create(&service).Error)
+
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		{ID: "deleted", Labels: owned("42")},
+	}, nil)
+	mockClient.On("ContainerRemove", mock.Anything, "deleted", container.RemoveOptions{Force: true}).Return(nil).Once()
+	mockClient.On("ContainerInspect", mock.Anything, "redis").Return(types.ContainerJSON{}, errdefs.NotFound(errors.New("no such container"))).Once()
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "redis:7").Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, "dockman-1-gone").Return(container.CreateResponse{ID: "redis-2"}, nil).Once()
+	mockClient.On("ContainerStart", mock.Anything, "redis-2", mock.Anything).Return(nil)
+
+	w := doRequest(router, "POST", "/api/drift/heal", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var response struct {
+		Removed []string     `json:"removed"`
+		Jobs    []models.Job `json:"jobs"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []string{"deleted"}, response.Removed)
+	require.Len(t, response.Jobs, 1)
+	jobs.Wait(response.Jobs[0].ID)
+
+	var deployment models.Deployment
+	require.NoError(t, database.DB.Where("service_id = ?", service.ID).First(&deployment).Error)
+	assert.Equal(t, models.DeploymentHeal, deployment.Kind)
+	assert.Equal(t, models.DeploymentSucceeded, deployment.Status)
+	var stored models.Service
+	require.NoError(t, database.DB.First(&stored, service.ID).Error)
+	assert.Equal(t, "redis-2", stored.ContainerID)
+	mockClient.AssertExpectations(t)
+}
@@ -8,8 +8,12 @@ package handlers
 import (
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
+	"docker-manager/api/internal/audit"
+	"docker-manager/api/internal/crypto"
 	"docker-manager/api/internal/database"
 	"docker-manager/api/internal/models"
 	"github.com/gin-gonic/gin"
@@ -39,6 +43,7 @@ func ListEnvironmentVariables(c *gin.Context) {
 	environmentID := c.Param("id")
 	var variables []models.EnvironmentVariable
 	if err := database.DB.Where("environment_id = ?", environmentID).Find(&variables).Error; err != nil {
+		log.Printf("Error listing environment variables: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list environment variables"})
 		return
 	}
@@ -49,7 +54,7 @@ func ListEnvironmentVariables(c *gin.Context) {
 func UpdateEnvironmentVariable(c *gin.Context) {
 	variableID := c.Param("varId")
 	var variable models.EnvironmentVariable
-	if err := database.DB.First(&variable, variableID).Error; err != nil {
+	if err := database.DB.Where("environment_id = ?", c.Param("id")).First(&variable, variableID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Environment variable not found"})
 		return
 	}
@@ -60,8 +65,10 @@ func UpdateEnvironmentVariable(c *gin.Context) {
 		return
 	}
 
+	before := variable
 	variable.Key = input.Key
 	variable.Value = input.Value
+	audit.SetChanges(c, before, variable)
 
 	if err := database.DB.Save(&variable).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update environment variable"})
@@ -73,9 +80,39 @@ func UpdateEnvironmentVariable(c *gin.Context) {
 // DeleteEnvironmentVariable deletes a variable.
 func DeleteEnvironmentVariable(c *gin.Context) {
 	variableID := c.Param("varId")
-	if err := database.DB.Delete(&models.EnvironmentVariable{}, variableID).Error; err != nil {
+	if err := database.DB.Where("environment_id = ?", c.Param("id")).Delete(&models.EnvironmentVariable{}, variableID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete environment variable"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Environment variable deleted"})
 }
+
+// environmentVariableList returns the decrypted variables of an environment as
+// KEY=value pairs sorted by key, ready to be handed to a container or process.
+func environmentVariableList(environmentID uint) ([]string, error) {
+	var variables []models.EnvironmentVariable
+	if err := database.DB.Where("environment_id = ?", environmentID).Find(&variables).Error; err != nil {
+		return nil, err
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Key < variables[j].Key })
+
+	env := make([]string, 0, len(variables))
+	for _, variable := range variables {
+		env = append(env, variable.Key+"="+variable.Value)
+	}
+	return env, nil
+}
+
+// environmentHash returns a keyed digest of a variable list so deployments
+// can tell whether the variables changed without storing the plaintext
+// values. The digest is readable by viewers, so it must not be a plain hash
+// that secret values could be guessed from.
+func environmentHash(env []string) (string, error) {
+	return crypto.Digest([]byte(strings.Join(env, "\n")))
+}
+
+// environmentMatches reports whether hash was returned by environmentHash
+// for env.
+func environmentMatches(env []string, hash string) bool {
+	return crypto.MatchesDigest([]byte(strings.Join(env, "\n")), hash)
+}
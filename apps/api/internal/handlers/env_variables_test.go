@@ -0,0 +1,35 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestListEnvironmentVariablesReportsTamperedValues(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.GET("/api/environments/:id/variables", ListEnvironmentVariables)
+
+	database.DB.Create(&models.EnvironmentVariable{Key: "TOKEN", Value: "abc", EnvironmentID: 1})
+	database.DB.Session(&gorm.Session{SkipHooks: true}).Model(&models.EnvironmentVariable{}).
+		Where("key = ?", "TOKEN").Update("value", "v2:new:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+
+	req, _ := http.NewRequest("GET", "/api/environments/1/variables", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "AAAA")
+}
@@ -9,11 +9,19 @@ import (
 	"net/http"
 	"strconv"
 
+	"docker-manager/api/internal/audit"
 	"docker-manager/api/internal/database"
 	"docker-manager/api/internal/models"
 	"github.com/gin-gonic/gin"
 )
 
+// environmentInput is the part of an environment clients can set. Services
+// and variables are created through their own endpoints, never along with
+// the environment.
+type environmentInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
 // CreateEnvironment handles the creation of a new environment for a project.
 func CreateEnvironment(c *gin.Context) {
 	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -22,18 +30,18 @@ func CreateEnvironment(c *gin.Context) {
 		return
 	}
 
-	var environment models.Environment
-	if err := c.ShouldBindJSON(&environment); err != nil {
+	var input environmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	environment.ProjectID = uint(projectID)
+	environment := models.Environment{Name: input.Name, ProjectID: uint(projectID)}
 
 	if err := database.DB.Create(&environment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create environment"})
 		return
 	}
+	createEnvironmentNetwork(c.Request.Context(), environment.ID)
 
 	c.JSON(http.StatusOK, environment)
 }
@@ -54,3 +62,40 @@ func ListEnvironments(c *gin.Context) {
 
 	c.JSON(http.StatusOK, environments)
 }
+
+// UpdateEnvironment renames an environment.
+func UpdateEnvironment(c *gin.Context) {
+	var environment models.Environment
+	if err := database.DB.First(&environment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+	var input environmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := environment
+	environment.Name = input.Name
+	audit.SetChanges(c, before, environment)
+	if err := database.DB.Save(&environment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update environment"})
+		return
+	}
+	c.JSON(http.StatusOK, environment)
+}
+
+// DeleteEnvironment deletes an environment with its services, variables and
+// members, after removing its network and the containers of its services from
+// the Docker host. Named volumes of compose stacks are only removed with
+// ?volumes=true. With ?dry_run=true, nothing is removed and the response
+// lists what would be.
+func DeleteEnvironment(c *gin.Context) {
+	var environment models.Environment
+	if err := database.DB.First(&environment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+	runTeardown(c, nil, []uint{environment.ID}, nil)
+}
@@ -0,0 +1,322 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/compose"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/events"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	dockerevents "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// eventsRetryInterval is how long WatchEvents waits before subscribing again
+// after the Docker event stream broke off.
+const eventsRetryInterval = 5 * time.Second
+
+// WatchEvents follows the container events of the Docker host until ctx is
+// canceled. It keeps the status of services in sync with their containers,
+// including changes made outside of DockMan, and publishes the events to
+// clients of /ws/events. Statuses are resynchronized from the container list
+// every time it subscribes, so nothing missed while disconnected is lost.
+func WatchEvents(ctx context.Context) {
+	for {
+		err := watchEvents(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Docker event stream interrupted, retrying in %s: %v", eventsRetryInterval, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRetryInterval):
+		}
+	}
+}
+
+// watchEvents subscribes to container events and handles them until the
+// stream fails.
+func watchEvents(ctx context.Context) error {
+	args := filters.NewArgs(filters.Arg("type", string(dockerevents.ContainerEventType)))
+	for _, action := range []string{events.Start, events.Die, events.OOM, events.HealthStatus, events.Destroy} {
+		args.Add("event", action)
+	}
+	messages, errs := DockerClient.Events(ctx, types.EventsOptions{Filters: args})
+
+	// Subscribe first so no change slips in between the sync and the stream.
+	if err := syncServiceStatuses(ctx); err != nil {
+		log.Printf("Error syncing service statuses: %v", err)
+	}
+	for {
+		select {
+		case message := <-messages:
+			handleContainerEvent(message)
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+// containerStatus maps the state of a container to a service status.
+func containerStatus(state string) string {
+	if state == "running" {
+		return models.ServiceRunning
+	}
+	return models.ServiceExited
+}
+
+// syncServiceStatuses sets the status of every deployed service from the
+// current state of its containers. A compose sub-service is running as long
+// as one of its replicas is.
+func syncServiceStatuses(ctx context.Context) error {
+	containers, err := DockerClient.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]types.Container, len(containers))
+	composeStatuses := map[string]string{}
+	for _, ctr := range containers {
+		byID[ctr.ID] = ctr
+		if projectName := ctr.Labels[compose.ProjectLabel]; projectName != "" {
+			key := projectName + "/" + ctr.Labels[compose.ServiceLabel]
+			if composeStatuses[key] != models.ServiceRunning {
+				composeStatuses[key] = containerStatus(ctr.State)
+			}
+		}
+	}
+
+	var services []models.Service
+	if err := database.DB.Find(&services).Error; err != nil {
+		return err
+	}
+	parents := map[uint]*models.Service{}
+	for i := range services {
+		if services[i].Type == "compose" {
+			parents[services[i].ID] = &services[i]
+		}
+	}
+
+	for _, service := range services {
+		var status string
+		switch {
+		case service.ContainerID != "":
+			ctr, ok := byID[service.ContainerID]
+			if !ok {
+				status = models.ServiceRemoved
+			} else {
+				status = containerStatus(ctr.State)
+			}
+		case service.ParentServiceID != nil && parents[*service.ParentServiceID] != nil:
+			status = composeStatuses[composeProjectName(parents[*service.ParentServiceID])+"/"+service.Name]
+			if status == "" && service.Status != "" {
+				status = models.ServiceRemoved
+			}
+		}
+		if status == "" || status == service.Status {
+			continue
+		}
+		if err := database.DB.Model(&service).Updates(serviceStatus(status, "", time.Now())).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serviceStatus returns the columns to update for a service to have status
+// and health.
+func serviceStatus(status, health string, changedAt time.Time) map[string]interface{} {
+	return map[string]interface{}{"status": status, "health": health, "status_changed_at": changedAt}
+}
+
+// eventService returns the service a container belongs to. Containers of a
+// compose service belong to the sub-service they were created for.
+func eventService(containerID string, labels map[string]string) (*models.Service, error) {
+	service, err := containerService(containerID, labels)
+	if err != nil || service.Type != "compose" {
+		return service, err
+	}
+	var subService models.Service
+	err = database.DB.Where("parent_service_id = ? AND name = ?", service.ID, labels[compose.ServiceLabel]).First(&subService).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return service, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subService, nil
+}
+
+// handleContainerEvent records the change a container event reports on the
+// service owning the container, if any, and publishes it.
+func handleContainerEvent(message dockerevents.Message) {
+	attributes := message.Actor.Attributes
+	if attributes[helperLabel] != "" {
+		return
+	}
+	event := events.Event{
+		ContainerID:   message.Actor.ID,
+		ContainerName: attributes["name"],
+		Image:         attributes["image"],
+		Time:          time.Unix(0, message.TimeNano),
+	}
+
+	// Health events read "health_status: healthy".
+	action := string(message.Action)
+	switch {
+	case action == events.Start:
+		event.Type = events.Start
+		event.Status = models.ServiceRunning
+	case action == events.Die:
+		event.Type = events.Die
+		event.Status = models.ServiceExited
+		if code, err := strconv.Atoi(attributes["exitCode"]); err == nil {
+			event.ExitCode = &code
+		}
+	case action == events.OOM:
+		event.Type = events.OOM
+	case strings.HasPrefix(action, events.HealthStatus):
+		event.Type = events.HealthStatus
+		event.Health = strings.TrimSpace(strings.TrimPrefix(action[len(events.HealthStatus):], ":"))
+	case action == events.Destroy:
+		event.Type = events.Destroy
+		event.Status = models.ServiceRemoved
+	default:
+		return
+	}
+
+	service, err := eventService(message.Actor.ID, attributes)
+	switch {
+	case errors.Is(err, auth.ErrScopeNotFound):
+		// Not a DockMan container.
+	case err != nil:
+		log.Printf("Error resolving the service of container %s: %v", message.Actor.ID, err)
+	default:
+		if err := recordContainerEvent(service, &event); err != nil {
+			log.Printf("Error updating status of service %d: %v", service.ID, err)
+		}
+	}
+	events.Publish(event)
+}
+
+// recordContainerEvent updates the status of the service a container event
+// is about and completes the event with the service and its scope.
+func recordContainerEvent(service *models.Service, event *events.Event) error {
+	event.ServiceID = service.ID
+	event.EnvironmentID = service.EnvironmentID
+	if scope, err := auth.ScopeOfEnvironment(service.EnvironmentID); err == nil {
+		event.ProjectID = scope.ProjectID
+	}
+
+	switch event.Type {
+	case events.OOM:
+		// The container is killed and a die event follows.
+		event.Status = service.Status
+		event.Health = service.Health
+		return nil
+	case events.HealthStatus:
+		event.Status = service.Status
+		return database.DB.Model(service).Updates(map[string]interface{}{"health": event.Health, "status_changed_at": event.Time}).Error
+	default:
+		return database.DB.Model(service).Updates(serviceStatus(event.Status, "", event.Time)).Error
+	}
+}
+
+// StreamEvents streams container events over a websocket as they happen.
+// Clients can narrow them with the project_id and environment_id query
+// parameters, and only receive the events of the projects they can see.
+// Administrators also receive the events of containers DockMan does not manage.
+func StreamEvents(c *gin.Context) {
+	var filter events.Filter
+	if id := c.Query("environment_id"); id != "" {
+		environmentID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid environment ID"})
+			return
+		}
+		scope, err := auth.ScopeOfEnvironment(uint(environmentID))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+			return
+		}
+		filter.EnvironmentID = scope.EnvironmentID
+		filter.ProjectID = scope.ProjectID
+	}
+	if id := c.Query("project_id"); id != "" {
+		projectID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			return
+		}
+		if filter.ProjectID != 0 && filter.ProjectID != uint(projectID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The environment does not belong to the project"})
+			return
+		}
+		filter.ProjectID = uint(projectID)
+	}
+
+	projectIDs, err := auth.VisibleProjectIDs(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve accessible projects"})
+		return
+	}
+	if projectIDs != nil && filter.ProjectID != 0 && !slices.Contains(projectIDs, filter.ProjectID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this project"})
+		return
+	}
+	filter.ProjectIDs = projectIDs
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Println("Failed to upgrade connection:", err)
+		return
+	}
+	defer ws.Close()
+
+	stream, unsubscribe := events.Subscribe(filter)
+	defer unsubscribe()
+
+	// Unsubscribe as soon as the client goes away, even while the host is quiet.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			if err := ws.WriteJSON(event); err != nil {
+				log.Println("Error writing to websocket:", err)
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
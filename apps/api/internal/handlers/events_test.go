@@ -0,0 +1,89 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/events"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	dockerevents "github.com/docker/docker/api/types/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// containerEvent builds a Docker event for a container.
+func containerEvent(action dockerevents.Action, containerID string, attributes map[string]string) dockerevents.Message {
+	return dockerevents.Message{
+		Type:     dockerevents.ContainerEventType,
+		Action:   action,
+		Actor:    dockerevents.Actor{ID: containerID, Attributes: attributes},
+		TimeNano: time.Now().UnixNano(),
+	}
+}
+
+func TestContainerEventsUpdateServiceStatus(t *testing.T) {
+	setupTestRouter(new(MockDockerClient))
+	project := models.Project{Name: "shop"}
+	require.NoError(t, database.DB.Create(&project).Error)
+	environment := models.Environment{Name: "dev", ProjectID: project.ID}
+	require.NoError(t, database.DB.Create(&environment).Error)
+	service := models.Service{Name: "api", Type: "container", ContainerID: "api-container", EnvironmentID: environment.ID, Status: models.ServiceRunning}
+	require.NoError(t, database.DB.Create(&service).Error)
+
+	stream, unsubscribe := events.Subscribe(events.Filter{ProjectIDs: []uint{project.ID}})
+	defer unsubscribe()
+
+	// Containers DockMan does not manage are not shown to project members.
+	handleContainerEvent(containerEvent(dockerevents.ActionDie, "unmanaged", map[string]string{"exitCode": "0"}))
+	handleContainerEvent(containerEvent(dockerevents.ActionHealthStatusUnhealthy, "api-container", map[string]string{"name": "dockman-1-api"}))
+	handleContainerEvent(containerEvent(dockerevents.ActionOOM, "api-container", nil))
+	handleContainerEvent(containerEvent(dockerevents.ActionDie, "api-container", map[string]string{"exitCode": "137"}))
+
+	event := <-stream
+	assert.Equal(t, events.HealthStatus, event.Type)
+	assert.Equal(t, "unhealthy", event.Health)
+	assert.Equal(t, "dockman-1-api", event.ContainerName)
+	assert.Equal(t, service.ID, event.ServiceID)
+	assert.Equal(t, environment.ID, event.EnvironmentID)
+	assert.Equal(t, project.ID, event.ProjectID)
+	event = <-stream
+	assert.Equal(t, events.OOM, event.Type)
+	assert.Equal(t, models.ServiceRunning, event.Status)
+	event = <-stream
+	assert.Equal(t, events.Die, event.Type)
+	assert.Equal(t, models.ServiceExited, event.Status)
+	require.NotNil(t, event.ExitCode)
+	assert.Equal(t, 137, *event.ExitCode)
+
+	var stored models.Service
+	require.NoError(t, database.DB.First(&stored, service.ID).Error)
+	assert.Equal(t, models.ServiceExited, stored.Status)
+	assert.Empty(t, stored.Health)
+	assert.NotNil(t, stored.StatusChangedAt)
+}
+
+func TestSyncServiceStatuses(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	setupTestRouter(mockClient)
+	running := models.Service{Name: "web", Type: "container", ContainerID: "web-container", EnvironmentID: 1}
+	removed := models.Service{Name: "worker", Type: "container", ContainerID: "gone", EnvironmentID: 1, Status: models.ServiceRunning}
+	require.NoError(t, database.DB.Create(&[]models.Service{running, removed}).Error)
+
+	// Containers removed while DockMan was not watching are noticed on resync.
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{{ID: "web-container", State: "running"}}, nil)
+	require.NoError(t, syncServiceStatuses(t.Context()))
+
+	var services []models.Service
+	require.NoError(t, database.DB.Order("id").Find(&services).Error)
+	assert.Equal(t, models.ServiceRunning, services[0].Status)
+	assert.Equal(t, models.ServiceRemoved, services[1].Status)
+}
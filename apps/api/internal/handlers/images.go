@@ -0,0 +1,103 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/jobs"
+	"docker-manager/api/internal/models"
+	"docker-manager/api/internal/pull"
+
+	"github.com/docker/docker/api/types"
+	"github.com/gin-gonic/gin"
+)
+
+// pullReportInterval is how often the progress of a pull is reported while
+// only byte counts change.
+const pullReportInterval = 250 * time.Millisecond
+
+// PullImage pulls an image in a background job, with the registry
+// credentials of the optional project or else the install-wide ones. Clients
+// follow the per-layer progress and overall percentage on /ws/jobs/:id and
+// cancel the pull with /api/jobs/:id/cancel.
+func PullImage(c *gin.Context) {
+	var input struct {
+		Image     string `json:"image" binding:"required"`
+		ProjectID uint   `json:"project_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	image := strings.TrimSpace(input.Image)
+	registryAuth, err := registryAuth(image, input.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load registry credentials"})
+		return
+	}
+
+	job := &models.Job{Kind: "image-pull"}
+	if user := auth.CurrentUser(c); user != nil {
+		job.CreatedByID = &user.ID
+		job.CreatedBy = user.Username
+	}
+	err = jobs.Start(job, "image:"+image, func(ctx context.Context, out io.Writer) error {
+		return pullImage(ctx, image, registryAuth, out)
+	})
+	if errors.Is(err, jobs.ErrBusy) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This image is already being pulled"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start pull"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Pull started", "job": job})
+}
+
+// pullImage pulls image with the encoded registryAuth login, writing every
+// status change to out and reporting its progress to the clients following
+// the job.
+func pullImage(ctx context.Context, image, registryAuth string, out io.Writer) error {
+	fmt.Fprintf(out, "Pulling image %s\n", image)
+	reader, err := DockerClient.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tracker := pull.NewTracker()
+	var reported time.Time
+	err = tracker.Read(reader, func(message pull.Message, changed bool) {
+		if changed {
+			if message.ID != "" {
+				fmt.Fprintf(out, "%s: %s\n", message.ID, message.Status)
+			} else {
+				fmt.Fprintln(out, message.Status)
+			}
+		}
+		if changed || time.Since(reported) >= pullReportInterval {
+			jobs.Report(out, tracker.Progress())
+			reported = time.Now()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	// Pulls of images that are up to date report no layers at all.
+	progress := tracker.Progress()
+	progress.Percent = 100
+	jobs.Report(out, progress)
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/jobs"
+	"docker-manager/api/internal/models"
+	"docker-manager/api/internal/pull"
+
+	"github.com/docker/docker/api/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// startPull starts a pull of image through the API, streaming the daemon's
+// progress from the returned pipe, and returns the pull's job.
+func startPull(t *testing.T, mockClient *MockDockerClient, server *httptest.Server, image string) (models.Job, *io.PipeWriter) {
+	reader, writer := io.Pipe()
+	mockClient.On("ImagePull", mock.Anything, image, types.ImagePullOptions{}).Return(reader, nil).Run(func(args mock.Arguments) {
+		// Like the Docker client, abort the stream once the pull is canceled.
+		ctx := args.Get(0).(context.Context)
+		go func() {
+			<-ctx.Done()
+			writer.CloseWithError(ctx.Err())
+		}()
+	}).Once()
+
+	resp, err := http.Post(server.URL+"/images/pull", "application/json", strings.NewReader(fmt.Sprintf(`{"image": %q}`, image)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var body struct {
+		Job models.Job `json:"job"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body.Job, writer
+}
+
+func TestPullImageStreamsProgress(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/images/pull", PullImage)
+	router.GET("/ws/jobs/:id", StreamJob)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	job, daemon := startPull(t, mockClient, server, "nginx:latest")
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+fmt.Sprintf("/ws/jobs/%d", job.ID), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	fmt.Fprintln(daemon, `{"status":"Pulling from library/nginx","id":"latest"}`)
+	fmt.Fprintln(daemon, `{"status":"Downloading","progressDetail":{"current":10,"total":40},"id":"a1"}`)
+	fmt.Fprintln(daemon, `{"status":"Pull complete","progressDetail":{},"id":"a1"}`)
+	daemon.Close()
+
+	var output string
+	var progress []pull.Progress
+	for {
+		var event struct {
+			Type     string        `json:"type"`
+			Data     string        `json:"data"`
+			Progress pull.Progress `json:"progress"`
+			Status   string        `json:"status"`
+		}
+		require.NoError(t, conn.ReadJSON(&event))
+		if event.Type == jobs.EventStatus {
+			assert.Equal(t, models.JobSucceeded, event.Status)
+			break
+		}
+		if event.Type == jobs.EventProgress {
+			progress = append(progress, event.Progress)
+		}
+		output += event.Data
+	}
+
+	assert.Equal(t, "Pulling image nginx:latest\nlatest: Pulling from library/nginx\na1: Downloading\na1: Pull complete\n", output)
+	require.NotEmpty(t, progress)
+	assert.Contains(t, progress, pull.Progress{
+		Status:  "Pulling from library/nginx",
+		Layers:  []pull.Layer{{ID: "a1", Status: pull.StatusDownloading, Current: 10, Total: 40}},
+		Percent: 10.0 / 40 * 80,
+	})
+	assert.Equal(t, 100.0, progress[len(progress)-1].Percent)
+}
+
+func TestCancelPullImage(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/images/pull", PullImage)
+	router.POST("/api/jobs/:id/cancel", CancelJob)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	job, daemon := startPull(t, mockClient, server, "postgres:16")
+	fmt.Fprintln(daemon, `{"status":"Downloading","progressDetail":{"current":1,"total":100},"id":"a1"}`)
+
+	// A second pull of the same image waits for the first one.
+	w := doRequest(router, "POST", "/images/pull", `{"image": "postgres:16"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(router, "POST", fmt.Sprintf("/api/jobs/%d/cancel", job.ID), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	jobs.Wait(job.ID)
+
+	var stored models.Job
+	require.NoError(t, database.DB.First(&stored, job.ID).Error)
+	assert.Equal(t, models.JobCanceled, stored.Status)
+	assert.Contains(t, stored.Output, "a1: Downloading")
+}
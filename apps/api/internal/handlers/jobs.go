@@ -0,0 +1,141 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/jobs"
+	"docker-manager/api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+// JobScope resolves the scope of a request from a job ID route parameter.
+// Jobs that do not belong to a service have no project and are only
+// accessible to administrators.
+func JobScope(param string) auth.ScopeResolver {
+	return func(c *gin.Context) (auth.Scope, error) {
+		var job models.Job
+		if err := database.DB.Select("id", "service_id").First(&job, c.Param(param)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return auth.Scope{}, auth.ErrScopeNotFound
+			}
+			return auth.Scope{}, err
+		}
+		if job.ServiceID == nil {
+			return auth.Scope{}, nil
+		}
+		var service models.Service
+		if err := database.DB.Select("id", "environment_id").First(&service, *job.ServiceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return auth.Scope{}, nil
+			}
+			return auth.Scope{}, err
+		}
+		return auth.ScopeOfEnvironment(service.EnvironmentID)
+	}
+}
+
+// ListServiceJobs lists the jobs of a service, most recent first, without their output.
+func ListServiceJobs(c *gin.Context) {
+	var list []models.Job
+	if err := database.DB.Omit("output").Where("service_id = ?", c.Param("id")).Order("id DESC").Limit(100).Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetJob returns a job with its status and, once it finished, its output.
+func GetJob(c *gin.Context) {
+	var job models.Job
+	if err := database.DB.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob asks a running job to stop.
+func CancelJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+	if err := jobs.Cancel(uint(id)); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job is not running"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Cancellation requested"})
+}
+
+// StreamJob streams the output of a job over a websocket: everything written
+// so far and its latest progress, then new output and progress as they are
+// produced, and finally the job's status.
+// Clients can reattach at any time, e.g. after a page reload.
+func StreamJob(c *gin.Context) {
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Println("Failed to upgrade connection:", err)
+		return
+	}
+	defer ws.Close()
+
+	jobID := c.Param("id")
+	id, err := strconv.ParseUint(jobID, 10, 32)
+	if err != nil {
+		ws.WriteMessage(websocket.TextMessage, []byte("Error: Invalid job ID."))
+		return
+	}
+
+	backlog, events, detach, err := jobs.Attach(uint(id))
+	if err != nil {
+		log.Printf("Error attaching to job %s: %v", jobID, err)
+		ws.WriteMessage(websocket.TextMessage, []byte("Error: Could not find job."))
+		return
+	}
+	defer detach()
+
+	// Detach as soon as the client goes away, even while the job is quiet.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range backlog {
+		if err := ws.WriteJSON(event); err != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := ws.WriteJSON(event); err != nil {
+				log.Println("Error writing to websocket:", err)
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
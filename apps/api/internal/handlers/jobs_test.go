@@ -0,0 +1,97 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/jobs"
+	"docker-manager/api/internal/models"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamJobReplaysOutputAndFollowsUntilDone(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.GET("/ws/jobs/:id", StreamJob)
+	router.POST("/api/jobs/:id/cancel", CancelJob)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	job := &models.Job{Kind: "test"}
+	require.NoError(t, jobs.Start(job, "test", func(ctx context.Context, out io.Writer) error {
+		fmt.Fprintln(out, "step 1")
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		fmt.Fprintln(out, "step 2")
+		return nil
+	}))
+	assert.ErrorIs(t, jobs.Start(&models.Job{Kind: "test"}, "test", nil), jobs.ErrBusy)
+
+	// A client attaching mid-job first receives the output so far.
+	<-started
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + fmt.Sprintf("/ws/jobs/%d", job.ID)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, jobs.Event{Type: jobs.EventOutput, Data: "step 1\n"}, readEvent(t, conn))
+
+	close(release)
+	assert.Equal(t, jobs.Event{Type: jobs.EventOutput, Data: "step 2\n"}, readEvent(t, conn))
+	assert.Equal(t, jobs.Event{Type: jobs.EventStatus, Status: models.JobSucceeded}, readEvent(t, conn))
+
+	// Reattaching after the job finished replays it from the database.
+	jobs.Wait(job.ID)
+	conn2, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn2.Close()
+	assert.Equal(t, "step 1\nstep 2\n", readEvent(t, conn2).Data)
+	assert.Equal(t, models.JobSucceeded, readEvent(t, conn2).Status)
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) jobs.Event {
+	var event jobs.Event
+	require.NoError(t, conn.ReadJSON(&event))
+	return event
+}
+
+func TestCancelJob(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/jobs/:id/cancel", CancelJob)
+
+	job := &models.Job{Kind: "test"}
+	require.NoError(t, jobs.Start(job, "", func(ctx context.Context, out io.Writer) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	w := doRequest(router, "POST", fmt.Sprintf("/api/jobs/%d/cancel", job.ID), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	jobs.Wait(job.ID)
+
+	var stored models.Job
+	require.NoError(t, database.DB.First(&stored, job.ID).Error)
+	assert.Equal(t, models.JobCanceled, stored.Status)
+
+	w = doRequest(router, "POST", fmt.Sprintf("/api/jobs/%d/cancel", job.ID), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
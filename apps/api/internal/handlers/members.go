@@ -0,0 +1,152 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type memberInput struct {
+	UserID uint        `json:"user_id" binding:"required"`
+	Role   models.Role `json:"role" binding:"required"`
+}
+
+// bindMember reads and validates a member assignment from the request body.
+func bindMember(c *gin.Context) (*memberInput, bool) {
+	var input memberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !input.Role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be one of viewer, developer, deployer or admin"})
+		return nil, false
+	}
+	if err := database.DB.First(&models.User{}, input.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		}
+		return nil, false
+	}
+	return &input, true
+}
+
+// ListProjectMembers lists the members of a project and their roles.
+func ListProjectMembers(c *gin.Context) {
+	var members []models.ProjectMember
+	if err := database.DB.Preload("User").Where("project_id = ?", c.Param("id")).Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list members"})
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// SetProjectMember adds a user to a project or changes their role.
+func SetProjectMember(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	input, ok := bindMember(c)
+	if !ok {
+		return
+	}
+
+	member := models.ProjectMember{ProjectID: uint(projectID), UserID: input.UserID, Role: input.Role}
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&member).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save member"})
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveProjectMember removes a user from a project, along with their
+// environment overrides in it.
+func RemoveProjectMember(c *gin.Context) {
+	projectID, userID := c.Param("id"), c.Param("userId")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		environments := tx.Model(&models.Environment{}).Select("id").Where("project_id = ?", projectID)
+		return tx.Unscoped().Where("user_id = ? AND environment_id IN (?)", userID, environments).Delete(&models.EnvironmentMember{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// ListEnvironmentMembers lists the role overrides of an environment.
+func ListEnvironmentMembers(c *gin.Context) {
+	var members []models.EnvironmentMember
+	if err := database.DB.Preload("User").Where("environment_id = ?", c.Param("id")).Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list members"})
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// SetEnvironmentMember overrides the project role of a member for one environment.
+func SetEnvironmentMember(c *gin.Context) {
+	var environment models.Environment
+	if err := database.DB.First(&environment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+	input, ok := bindMember(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Where("project_id = ? AND user_id = ?", environment.ProjectID, input.UserID).First(&models.ProjectMember{}).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User is not a member of the project"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch member"})
+		return
+	}
+
+	member := models.EnvironmentMember{EnvironmentID: environment.ID, UserID: input.UserID, Role: input.Role}
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "environment_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&member).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save member"})
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveEnvironmentMember removes a role override, so the member's project role applies again.
+func RemoveEnvironmentMember(c *gin.Context) {
+	err := database.DB.Unscoped().Where("environment_id = ? AND user_id = ?", c.Param("id"), c.Param("userId")).Delete(&models.EnvironmentMember{}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member override removed"})
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRBACRouter registers project, environment and service endpoints behind role checks.
+func setupRBACRouter(mockClient *MockDockerClient) *gin.Engine {
+	router := setupAuthRouter(mockClient)
+	protected := router.Group("/api", auth.Required())
+	protected.POST("/projects", auth.KeyScopeRequired(models.RoleAdmin), CreateProject)
+	protected.GET("/projects", ListProjects)
+	protected.GET("/projects/:id", auth.RequireRole(models.RoleViewer, auth.ProjectScope("id")), GetProject)
+	protected.POST("/projects/:id/members", auth.RequireRole(models.RoleAdmin, auth.ProjectScope("id")), SetProjectMember)
+	protected.POST("/environments/:id/members", auth.RequireRole(models.RoleAdmin, auth.ProjectOnly(auth.EnvironmentScope("id"))), SetEnvironmentMember)
+	protected.POST("/environments/:id/variables", auth.RequireRole(models.RoleDeveloper, auth.EnvironmentScope("id")), CreateEnvironmentVariable)
+	protected.POST("/services/:id/up", auth.RequireRole(models.RoleDeployer, auth.ServiceScope("id")), UpService)
+	return router
+}
+
+func TestRoleChecksWithEnvironmentOverrides(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupRBACRouter(mockClient)
+
+	w := doRequest(router, "POST", "/api/auth/setup", `{"username": "admin", "password": "correct horse"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	admin := sessionCookie(t, w)
+	w = doRequest(router, "POST", "/api/users", `{"username": "dev", "password": "battery staple"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "POST", "/api/users", `{"username": "outsider", "password": "battery staple"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(router, "POST", "/api/projects", `{"name": "shop"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	dev := models.Environment{Name: "dev", ProjectID: 1}
+	prod := models.Environment{Name: "prod", ProjectID: 1}
+	database.DB.Create(&dev)
+	database.DB.Create(&prod)
+	devService := models.Service{Name: "web", Type: "container", Image: "nginx:latest", EnvironmentID: dev.ID}
+	prodService := models.Service{Name: "web", Type: "container", Image: "nginx:latest", EnvironmentID: prod.ID}
+	database.DB.Create(&devService)
+	database.DB.Create(&prodService)
+
+	// Developers deploy to dev through an override but cannot touch prod.
+	w = doRequest(router, "POST", "/api/projects/1/members", `{"user_id": 2, "role": "developer"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "POST", fmt.Sprintf("/api/environments/%d/members", dev.ID), `{"user_id": 2, "role": "deployer"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "POST", fmt.Sprintf("/api/environments/%d/members", prod.ID), `{"user_id": 2, "role": "viewer"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(router, "POST", "/api/auth/login", `{"username": "dev", "password": "battery staple"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	developer := sessionCookie(t, w)
+
+	mockClient.On("NetworkInspect", mock.Anything, mock.Anything, mock.Anything).Return(types.NetworkResource{}, nil)
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "nginx:latest").Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(container.CreateResponse{ID: "dev-container"}, nil)
+	mockClient.On("ContainerStart", mock.Anything, "dev-container", mock.Anything).Return(nil)
+
+	w = doRequest(router, "POST", fmt.Sprintf("/api/services/%d/up", devService.ID), "", developer)
+	assert.Equal(t, models.JobSucceeded, waitForJob(t, w).Status)
+	w = doRequest(router, "POST", fmt.Sprintf("/api/services/%d/up", prodService.ID), "", developer)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doRequest(router, "POST", fmt.Sprintf("/api/environments/%d/variables", prod.ID), `{"key": "A", "value": "b"}`, developer)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doRequest(router, "GET", "/api/projects/1", "", developer)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "POST", "/api/projects/1/members", `{"user_id": 3, "role": "viewer"}`, developer)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Users outside the project do not see it at all.
+	w = doRequest(router, "POST", "/api/auth/login", `{"username": "outsider", "password": "battery staple"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	outsider := sessionCookie(t, w)
+	w = doRequest(router, "GET", "/api/projects/1", "", outsider)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest(router, "GET", "/api/projects", "", outsider)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
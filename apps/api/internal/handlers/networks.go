@@ -0,0 +1,216 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Labels recording which project, environment and service the containers and
+// networks DockMan creates belong to.
+const (
+	environmentLabel = "dockman.environment"
+	projectLabel     = "dockman.project"
+	serviceLabel     = "dockman.service"
+)
+
+// predefinedNetworks are created by Docker itself and cannot be removed.
+var predefinedNetworks = map[string]bool{"bridge": true, "host": true, "none": true}
+
+// environmentNetworkName returns the name of the bridge network the services
+// of an environment share.
+func environmentNetworkName(environmentID uint) string {
+	return fmt.Sprintf("dockman-env-%d", environmentID)
+}
+
+// ensureEnvironmentNetwork creates the bridge network of an environment if it
+// does not exist yet, and returns its name. Services deployed into the
+// environment join it, so they reach each other by name while environments
+// stay isolated from one another. It returns an empty name for services
+// outside of any environment.
+func ensureEnvironmentNetwork(ctx context.Context, environmentID uint) (string, error) {
+	var environment models.Environment
+	if err := database.DB.Select("id", "project_id").First(&environment, environmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	name := environmentNetworkName(environment.ID)
+	if _, err := DockerClient.NetworkInspect(ctx, name, types.NetworkInspectOptions{}); err == nil {
+		return name, nil
+	} else if !client.IsErrNotFound(err) {
+		return "", err
+	}
+	_, err := DockerClient.NetworkCreate(ctx, name, types.NetworkCreate{
+		Driver: "bridge",
+		Labels: map[string]string{
+			environmentLabel: strconv.FormatUint(uint64(environment.ID), 10),
+			projectLabel:     strconv.FormatUint(uint64(environment.ProjectID), 10),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("create network %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// createEnvironmentNetwork creates the network of a new environment. Failures
+// are only logged: the network is created again on the first deployment.
+func createEnvironmentNetwork(ctx context.Context, environmentID uint) {
+	if _, err := ensureEnvironmentNetwork(ctx, environmentID); err != nil {
+		log.Printf("Error creating network of environment %d: %v", environmentID, err)
+	}
+}
+
+// ListNetworks lists the Docker networks on the host.
+func ListNetworks(c *gin.Context) {
+	networks, err := DockerClient.NetworkList(c.Request.Context(), types.NetworkListOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list networks"})
+		return
+	}
+	c.JSON(http.StatusOK, networks)
+}
+
+// GetNetwork returns a network with the containers connected to it.
+func GetNetwork(c *gin.Context) {
+	resource, err := DockerClient.NetworkInspect(c.Request.Context(), c.Param("id"), types.NetworkInspectOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Network not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to inspect network"})
+		return
+	}
+	c.JSON(http.StatusOK, resource)
+}
+
+// CreateNetwork creates a network.
+func CreateNetwork(c *gin.Context) {
+	var input struct {
+		Name       string            `json:"name" binding:"required"`
+		Driver     string            `json:"driver"`
+		Internal   bool              `json:"internal"`
+		Attachable bool              `json:"attachable"`
+		Subnet     string            `json:"subnet"`
+		Gateway    string            `json:"gateway"`
+		Labels     map[string]string `json:"labels"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for key := range input.Labels {
+		if strings.HasPrefix(key, "dockman.") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Labels starting with dockman. are reserved"})
+			return
+		}
+	}
+	if input.Driver == "" {
+		input.Driver = "bridge"
+	}
+
+	options := types.NetworkCreate{
+		Driver:     input.Driver,
+		Internal:   input.Internal,
+		Attachable: input.Attachable,
+		Labels:     input.Labels,
+	}
+	if input.Subnet != "" || input.Gateway != "" {
+		options.IPAM = &network.IPAM{Config: []network.IPAMConfig{{Subnet: input.Subnet, Gateway: input.Gateway}}}
+	}
+	resp, err := DockerClient.NetworkCreate(c.Request.Context(), strings.TrimSpace(input.Name), options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create network: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ID": resp.ID, "warning": resp.Warning})
+}
+
+// DeleteNetwork removes a network. Docker's own networks and the networks of
+// existing environments cannot be removed.
+func DeleteNetwork(c *gin.Context) {
+	ctx := c.Request.Context()
+	resource, err := DockerClient.NetworkInspect(ctx, c.Param("id"), types.NetworkInspectOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Network not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to inspect network"})
+		return
+	}
+	if predefinedNetworks[resource.Name] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Docker's predefined networks cannot be removed"})
+		return
+	}
+	if id := resource.Labels[environmentLabel]; id != "" {
+		var count int64
+		database.DB.Model(&models.Environment{}).Where("id = ?", id).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "This network belongs to an environment and is removed with it"})
+			return
+		}
+	}
+	if err := DockerClient.NetworkRemove(ctx, resource.ID); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to remove network: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// ConnectNetwork connects a container to a network, optionally under extra aliases.
+func ConnectNetwork(c *gin.Context) {
+	var input struct {
+		Container string   `json:"container" binding:"required"`
+		Aliases   []string `json:"aliases"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := DockerClient.NetworkConnect(c.Request.Context(), c.Param("id"), input.Container, &network.EndpointSettings{Aliases: input.Aliases})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to connect container: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "connected"})
+}
+
+// DisconnectNetwork disconnects a container from a network.
+func DisconnectNetwork(c *gin.Context) {
+	var input struct {
+		Container string `json:"container" binding:"required"`
+		Force     bool   `json:"force"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := DockerClient.NetworkDisconnect(c.Request.Context(), c.Param("id"), input.Container, input.Force); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to disconnect container: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "disconnected"})
+}
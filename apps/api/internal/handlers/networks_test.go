@@ -0,0 +1,81 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServicesJoinTheirEnvironmentNetwork(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/projects/:id/environments", CreateEnvironment)
+	router.POST("/api/services/:id/up", UpService)
+	project := models.Project{Name: "shop"}
+	require.NoError(t, database.DB.Create(&project).Error)
+
+	// Each new environment gets its own bridge network.
+	mockClient.On("NetworkInspect", mock.Anything, "dockman-env-1", mock.Anything).Return(types.NetworkResource{}, errdefs.NotFound(errors.New("network not found"))).Once()
+	mockClient.On("NetworkCreate", mock.Anything, "dockman-env-1", types.NetworkCreate{
+		Driver: "bridge",
+		Labels: map[string]string{environmentLabel: "1", projectLabel: "1"},
+	}).Return(types.NetworkCreateResponse{ID: "net-1"}, nil).Once()
+	w := doRequest(router, "POST", "/api/projects/1/environments", `{"name": "dev"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Containers join it under the name of their service.
+	service := models.Service{Name: "Orders API", Type: "container", Image: "orders:1", EnvironmentID: 1}
+	require.NoError(t, database.DB.Create(&service).Error)
+	mockClient.On("NetworkInspect", mock.Anything, "dockman-env-1", mock.Anything).Return(types.NetworkResource{ID: "net-1"}, nil)
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "orders:1").Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(networking *network.NetworkingConfig) bool {
+		endpoint := networking.EndpointsConfig["dockman-env-1"]
+		return endpoint != nil && assert.ObjectsAreEqual([]string{"orders-api"}, endpoint.Aliases)
+	}), mock.Anything, mock.Anything).Return(container.CreateResponse{ID: "orders"}, nil).Once()
+	mockClient.On("ContainerStart", mock.Anything, "orders", mock.Anything).Return(nil)
+
+	w = doRequest(router, "POST", "/api/services/1/up", "")
+	assert.Equal(t, models.JobSucceeded, waitForJob(t, w).Status)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDeleteNetworkProtectsSharedNetworks(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.DELETE("/networks/:id", DeleteNetwork)
+	environment := models.Environment{Name: "dev", ProjectID: 1}
+	require.NoError(t, database.DB.Create(&environment).Error)
+
+	mockClient.On("NetworkInspect", mock.Anything, "bridge", mock.Anything).Return(types.NetworkResource{ID: "b", Name: "bridge"}, nil)
+	w := doRequest(router, "DELETE", "/networks/bridge", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	mockClient.On("NetworkInspect", mock.Anything, "dockman-env-1", mock.Anything).Return(types.NetworkResource{
+		ID: "net-1", Name: "dockman-env-1", Labels: map[string]string{environmentLabel: "1"},
+	}, nil)
+	w = doRequest(router, "DELETE", "/networks/dockman-env-1", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	mockClient.On("NetworkInspect", mock.Anything, "scratch", mock.Anything).Return(types.NetworkResource{ID: "net-2", Name: "scratch"}, nil)
+	mockClient.On("NetworkRemove", mock.Anything, "net-2").Return(nil).Once()
+	w = doRequest(router, "DELETE", "/networks/scratch", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockClient.AssertNotCalled(t, "NetworkRemove", mock.Anything, "b")
+	mockClient.AssertNotCalled(t, "NetworkRemove", mock.Anything, "net-1")
+}
@@ -12,21 +12,41 @@ import (
 
 	"gorm.io/gorm"
 
+	"docker-manager/api/internal/audit"
+	"docker-manager/api/internal/auth"
 	"docker-manager/api/internal/database"
 	"docker-manager/api/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-// CreateProject handles the creation of a new project.
+// projectInput is the part of a project clients can set. Environments are
+// created through their own endpoint, never along with the project.
+type projectInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// CreateProject handles the creation of a new project. The creator becomes its admin.
 func CreateProject(c *gin.Context) {
-	var project models.Project
-	if err := c.ShouldBindJSON(&project); err != nil {
+	var input projectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	project := models.Project{Name: input.Name, Description: input.Description}
 
-	if err := database.DB.Create(&project).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+		user := auth.CurrentUser(c)
+		if user == nil {
+			return nil
+		}
+		return tx.Create(&models.ProjectMember{ProjectID: project.ID, UserID: user.ID, Role: models.RoleAdmin}).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
 		return
 	}
@@ -56,10 +76,20 @@ func GetProject(c *gin.Context) {
 	c.JSON(http.StatusOK, project)
 }
 
-// ListProjects handles listing all projects.
+// ListProjects handles listing the projects the current user is a member of.
 func ListProjects(c *gin.Context) {
+	projectIDs, err := auth.VisibleProjectIDs(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list projects"})
+		return
+	}
+	query := database.DB
+	if projectIDs != nil {
+		query = query.Where("id IN ?", projectIDs)
+	}
+
 	var projects []models.Project
-	if err := database.DB.Find(&projects).Error; err != nil {
+	if err := query.Find(&projects).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list projects"})
 		return
 	}
@@ -67,3 +97,40 @@ func ListProjects(c *gin.Context) {
 	c.JSON(http.StatusOK, projects)
 }
 
+// UpdateProject renames a project and replaces its description.
+func UpdateProject(c *gin.Context) {
+	var project models.Project
+	if err := database.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	var input projectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := project
+	project.Name = input.Name
+	project.Description = input.Description
+	audit.SetChanges(c, before, project)
+	if err := database.DB.Save(&project).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+	c.JSON(http.StatusOK, project)
+}
+
+// DeleteProject deletes a project with its environments, services, variables,
+// members and registry credentials, after removing the containers and
+// networks they own from the Docker host. Named volumes of compose stacks are
+// only removed with ?volumes=true. With ?dry_run=true, nothing is removed and
+// the response lists what would be.
+func DeleteProject(c *gin.Context) {
+	var project models.Project
+	if err := database.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	runTeardown(c, []uint{project.ID}, nil, nil)
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-manager/api/internal/audit"
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Docker Hub, the registry of images referenced without a host, and the
+// address Docker logs in to it with.
+const (
+	dockerHub       = "docker.io"
+	dockerHubServer = "https://index.docker.io/v1/"
+)
+
+// registryLoginTimeout bounds how long testing a registry login may take.
+const registryLoginTimeout = 30 * time.Second
+
+// normalizeRegistry turns a registry address entered by a user, such as
+// "https://registry.example.com:5000/v2/", into the host image references
+// name, here "registry.example.com:5000".
+func normalizeRegistry(address string) string {
+	address = strings.ToLower(strings.TrimSpace(address))
+	address = strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+	address, _, _ = strings.Cut(address, "/")
+	switch address {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHub
+	}
+	return address
+}
+
+// registryHost returns the host of the registry an image is pulled from.
+func registryHost(image string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
+
+// registryAuthConfig returns the login Docker uses for a credential.
+func registryAuthConfig(credential *models.RegistryCredential) registry.AuthConfig {
+	server := credential.Registry
+	if server == dockerHub {
+		server = dockerHubServer
+	}
+	return registry.AuthConfig{Username: credential.Username, Password: credential.Password, ServerAddress: server}
+}
+
+// registryAuth returns the encoded login to pull image with: the credential
+// of projectID for the image's registry, or else the install-wide one. It
+// returns an empty string when there is no credential for the registry.
+func registryAuth(image string, projectID uint) (string, error) {
+	host := registryHost(image)
+	if host == "" {
+		return "", nil
+	}
+	query := database.DB.Where("registry = ?", host)
+	if projectID != 0 {
+		query = query.Where("project_id = ? OR project_id IS NULL", projectID).Order("project_id IS NULL")
+	} else {
+		query = query.Where("project_id IS NULL")
+	}
+	var credentials []models.RegistryCredential
+	if err := query.Limit(1).Find(&credentials).Error; err != nil || len(credentials) == 0 {
+		return "", err
+	}
+	return registry.EncodeAuthConfig(registryAuthConfig(&credentials[0]))
+}
+
+// serviceRegistryAuth returns a function resolving the login to pull the
+// images of a service with, using the credentials of its project.
+func serviceRegistryAuth(service *models.Service) func(image string) (string, error) {
+	return func(image string) (string, error) {
+		scope, err := auth.ScopeOfEnvironment(service.EnvironmentID)
+		if err != nil && !errors.Is(err, auth.ErrScopeNotFound) {
+			return "", err
+		}
+		return registryAuth(image, scope.ProjectID)
+	}
+}
+
+// credentialScope restricts a query to the credentials of the project in the
+// route, or to the install-wide credentials on routes without a project.
+func credentialScope(c *gin.Context) (*uint, func(*gorm.DB) *gorm.DB) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, func(db *gorm.DB) *gorm.DB { return db.Where("project_id IS NULL") }
+	}
+	projectID := uint(id)
+	return &projectID, func(db *gorm.DB) *gorm.DB { return db.Where("project_id = ?", projectID) }
+}
+
+// findRegistryCredential loads the credential in the route, responding with
+// an error if it does not exist in the route's scope.
+func findRegistryCredential(c *gin.Context) (*models.RegistryCredential, bool) {
+	_, scope := credentialScope(c)
+	var credential models.RegistryCredential
+	if err := database.DB.Scopes(scope).First(&credential, c.Param("credentialId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry credential not found"})
+		return nil, false
+	}
+	return &credential, true
+}
+
+// ListRegistryCredentials lists the registry credentials of a project, or
+// the install-wide ones. Passwords are never returned.
+func ListRegistryCredentials(c *gin.Context) {
+	_, scope := credentialScope(c)
+	var credentials []models.RegistryCredential
+	if err := database.DB.Scopes(scope).Order("registry").Find(&credentials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list registry credentials"})
+		return
+	}
+	c.JSON(http.StatusOK, credentials)
+}
+
+// CreateRegistryCredential stores the login to a registry for a project, or
+// for the whole install. There is at most one credential per registry in each.
+func CreateRegistryCredential(c *gin.Context) {
+	var input struct {
+		Registry string `json:"registry" binding:"required"`
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	host := normalizeRegistry(input.Registry)
+	if host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registry address"})
+		return
+	}
+
+	projectID, scope := credentialScope(c)
+	var count int64
+	if err := database.DB.Model(&models.RegistryCredential{}).Scopes(scope).Where("registry = ?", host).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create registry credential"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A credential for this registry already exists"})
+		return
+	}
+
+	credential := models.RegistryCredential{
+		Registry:  host,
+		ProjectID: projectID,
+		Username:  strings.TrimSpace(input.Username),
+		Password:  input.Password,
+	}
+	if err := database.DB.Create(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create registry credential"})
+		return
+	}
+	c.JSON(http.StatusOK, credential)
+}
+
+// UpdateRegistryCredential changes the username or password of a credential.
+func UpdateRegistryCredential(c *gin.Context) {
+	credential, ok := findRegistryCredential(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := *credential
+	if username := strings.TrimSpace(input.Username); username != "" {
+		credential.Username = username
+	}
+	if input.Password != "" {
+		credential.Password = input.Password
+	}
+	audit.SetChanges(c, before, credential)
+	if err := database.DB.Save(credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update registry credential"})
+		return
+	}
+	c.JSON(http.StatusOK, credential)
+}
+
+// DeleteRegistryCredential deletes a credential.
+func DeleteRegistryCredential(c *gin.Context) {
+	credential, ok := findRegistryCredential(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Unscoped().Delete(credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete registry credential"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Registry credential deleted"})
+}
+
+// TestRegistryCredential logs the Docker daemon in to the registry of a
+// credential to check that it is valid.
+func TestRegistryCredential(c *gin.Context) {
+	credential, ok := findRegistryCredential(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), registryLoginTimeout)
+	defer cancel()
+	result, err := DockerClient.RegistryLogin(ctx, registryAuthConfig(credential))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login failed: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": result.Status})
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types/registry"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupRegistryRouter registers the install-wide and project registry credential endpoints.
+func setupRegistryRouter(mockClient *MockDockerClient) *gin.Engine {
+	router := setupTestRouter(mockClient)
+	for _, prefix := range []string{"/api/registries", "/api/projects/:id/registries"} {
+		router.GET(prefix, ListRegistryCredentials)
+		router.POST(prefix, CreateRegistryCredential)
+		router.PUT(prefix+"/:credentialId", UpdateRegistryCredential)
+		router.DELETE(prefix+"/:credentialId", DeleteRegistryCredential)
+		router.POST(prefix+"/:credentialId/test", TestRegistryCredential)
+	}
+	return router
+}
+
+// decodeRegistryAuth decodes the login registryAuth returns.
+func decodeRegistryAuth(t *testing.T, encoded string) registry.AuthConfig {
+	data, err := base64.URLEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	var config registry.AuthConfig
+	require.NoError(t, json.Unmarshal(data, &config))
+	return config
+}
+
+func TestRegistryCredentialsArePickedByRegistryAndProject(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupRegistryRouter(mockClient)
+	project := models.Project{Name: "shop"}
+	require.NoError(t, database.DB.Create(&project).Error)
+
+	w := doRequest(router, "POST", "/api/registries", `{"registry": "https://Registry.example.com:5000/v2/", "username": "ci", "password": "global-secret"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "global-secret")
+	w = doRequest(router, "POST", "/api/registries", `{"registry": "registry.example.com:5000", "username": "other", "password": "x"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doRequest(router, "POST", "/api/projects/1/registries", `{"registry": "registry.example.com:5000", "username": "shop", "password": "project-secret"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "POST", "/api/registries", `{"registry": "index.docker.io", "username": "hub", "password": "hub-secret"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Passwords are encrypted at rest.
+	var stored models.RegistryCredential
+	require.NoError(t, database.DB.Session(&gorm.Session{SkipHooks: true}).Where("username = ?", "ci").First(&stored).Error)
+	assert.NotContains(t, stored.Password, "global-secret")
+
+	// A project's own credential takes precedence over the install-wide one.
+	encoded, err := registryAuth("registry.example.com:5000/shop/api:1.2", project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, registry.AuthConfig{Username: "shop", Password: "project-secret", ServerAddress: "registry.example.com:5000"}, decodeRegistryAuth(t, encoded))
+	encoded, err = registryAuth("registry.example.com:5000/shop/api:1.2", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "global-secret", decodeRegistryAuth(t, encoded).Password)
+
+	// Images without a host come from Docker Hub.
+	encoded, err = registryAuth("nginx:latest", project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, registry.AuthConfig{Username: "hub", Password: "hub-secret", ServerAddress: dockerHubServer}, decodeRegistryAuth(t, encoded))
+
+	encoded, err = registryAuth("ghcr.io/acme/tool", project.ID)
+	require.NoError(t, err)
+	assert.Empty(t, encoded)
+
+	// Project routes only see the project's credentials.
+	var listed []models.RegistryCredential
+	w = doRequest(router, "GET", "/api/projects/1/registries", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, "shop", listed[0].Username)
+	w = doRequest(router, "DELETE", "/api/projects/1/registries/1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTestRegistryCredential(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupRegistryRouter(mockClient)
+
+	w := doRequest(router, "POST", "/api/registries", `{"registry": "localhost:5000", "username": "ci", "password": "wrong"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	login := registry.AuthConfig{Username: "ci", Password: "wrong", ServerAddress: "localhost:5000"}
+	mockClient.On("RegistryLogin", mock.Anything, login).Return(registry.AuthenticateOKBody{}, errors.New("unauthorized: authentication required")).Once()
+	w = doRequest(router, "POST", "/api/registries/1/test", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "authentication required")
+
+	w = doRequest(router, "PUT", "/api/registries/1", `{"password": "right"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	login.Password = "right"
+	mockClient.On("RegistryLogin", mock.Anything, login).Return(registry.AuthenticateOKBody{Status: "Login Succeeded"}, nil).Once()
+	w = doRequest(router, "POST", "/api/registries/1/test", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Login Succeeded")
+	mockClient.AssertExpectations(t)
+}
@@ -5,15 +5,29 @@
 
 package handlers
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	"docker-manager/api/internal/audit"
+	"docker-manager/api/internal/compose"
 	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/jobs"
 	"docker-manager/api/internal/models"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 // ListServices handles listing all top-level services for an environment.
 func ListServices(c *gin.Context) {
@@ -39,40 +53,199 @@ func GetServiceDetails(c *gin.Context) {
 // CreateService handles the creation of a new service in an environment.
 func CreateService(c *gin.Context) {
 	environmentID := c.Param("id")
-	var service models.Service
-	if err := c.ShouldBindJSON(&service); err != nil {
+	var input serviceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	envID, _ := strconv.Atoi(environmentID)
-	service.EnvironmentID = uint(envID)
+	service := models.Service{EnvironmentID: uint(envID), Type: input.Type}
+	input.apply(&service)
+
+	composeFile, ok := prepareService(c, &service)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&service).Error; err != nil {
+			return err
+		}
+		if composeFile != nil {
+			// The project name is derived from the name of the service, which
+			// can change, so it is fixed once the ID it includes is known.
+			service.ComposeProject = composeProjectName(&service)
+			if err := tx.Model(&service).Update("compose_project", service.ComposeProject).Error; err != nil {
+				return err
+			}
+			return syncComposeSubServices(tx, &service, composeFile)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service"})
+		return
+	}
+
+	c.JSON(http.StatusOK, service)
+}
+
+// serviceInput is the configuration of a service that clients can set. The
+// container, its state and the service hierarchy are managed by DockMan and
+// never taken from a request.
+type serviceInput struct {
+	Name        string                `json:"name" binding:"required"`
+	Type        string                `json:"type"`
+	Image       string                `json:"image"`
+	Spec        *models.ContainerSpec `json:"spec"`
+	ComposePath string                `json:"compose_path"`
+	GitRepoURL  string                `json:"git_repo_url"`
+	GitBranch   string                `json:"git_branch"`
+}
+
+// apply copies the input onto service, leaving its type unchanged.
+func (input serviceInput) apply(service *models.Service) {
+	service.Name = input.Name
+	service.Image = input.Image
+	service.Spec = input.Spec
+	service.ComposePath = input.ComposePath
+	service.GitRepoURL = input.GitRepoURL
+	service.GitBranch = input.GitBranch
+}
 
+// prepareService validates the configuration of a top-level service and, for
+// compose services, loads its compose file. It responds with the error and
+// returns false if the configuration is invalid.
+func prepareService(c *gin.Context, service *models.Service) (*compose.File, bool) {
 	switch service.Type {
 	case "container":
 		if service.Image == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Image is required for container type"})
-			return
+			return nil, false
+		}
+		if service.Spec != nil {
+			if err := service.Spec.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid container spec: " + err.Error()})
+				return nil, false
+			}
+			var sources []string
+			for _, mount := range service.Spec.Mounts {
+				if mount.Type == models.MountBind {
+					sources = append(sources, mount.Source)
+				}
+			}
+			if !checkBindMounts(c, sources) {
+				return nil, false
+			}
 		}
+		return nil, true
 	case "compose":
+		if service.Spec != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A container spec only applies to container services"})
+			return nil, false
+		}
 		if service.ComposePath == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "ComposePath is required for compose type"})
-			return
+			return nil, false
+		}
+		env, err := environmentVariableList(service.EnvironmentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load environment variables"})
+			return nil, false
+		}
+		composeFile, err := compose.Load(service.ComposePath, env)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid compose file: " + err.Error()})
+			return nil, false
+		}
+		sources, err := composeFile.BindSources(filepath.Dir(service.ComposePath))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve bind mounts: " + err.Error()})
+			return nil, false
 		}
+		if !checkBindMounts(c, sources) {
+			return nil, false
+		}
+		return composeFile, true
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service type"})
+		return nil, false
+	}
+}
+
+// UpdateService replaces the configuration of a service. The type of a
+// service cannot change. Running containers keep their configuration until
+// the service is deployed again; the drift report lists them meanwhile.
+func UpdateService(c *gin.Context) {
+	var service models.Service
+	if err := database.DB.First(&service, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	if service.ParentServiceID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-services are managed by their parent compose service"})
 		return
 	}
 
-	if err := database.DB.Create(&service).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service"})
+	var input serviceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Type != "" && input.Type != service.Type {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The type of a service cannot be changed"})
 		return
 	}
 
+	if service.Type == "compose" {
+		// Renaming must not move the stack of a service created before its
+		// project name was stored to a new project.
+		service.ComposeProject = composeProjectName(&service)
+	}
+	before := service
+	input.apply(&service)
+	composeFile, ok := prepareService(c, &service)
+	if !ok {
+		return
+	}
+	audit.SetChanges(c, before, service)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&service).Error; err != nil {
+			return err
+		}
+		if composeFile != nil {
+			return syncComposeSubServices(tx, &service, composeFile)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service"})
+		return
+	}
 	c.JSON(http.StatusOK, service)
 }
 
-// UpService handles starting a service.
+// DeleteService deletes a service and its sub-services, after removing their
+// containers, and the networks of compose stacks, from the Docker host. Named
+// volumes of compose stacks are only removed with ?volumes=true. With
+// ?dry_run=true, nothing is removed and the response lists what would be.
+func DeleteService(c *gin.Context) {
+	var service models.Service
+	if err := database.DB.First(&service, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	if service.ParentServiceID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-services are managed by their parent compose service"})
+		return
+	}
+	runTeardown(c, nil, nil, []uint{service.ID})
+}
+
+// UpService starts, or redeploys, a service in a background job and responds
+// with the job. Every run is recorded as a Deployment.
 func UpService(c *gin.Context) {
 	serviceID := c.Param("id") // Corrected to use ID from the URL path
 	var service models.Service
@@ -80,18 +253,52 @@ func UpService(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
 	}
+	if service.ParentServiceID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-services are managed by their parent compose service"})
+		return
+	}
+
+	deployment := newDeployment(c, &service, models.DeploymentUp)
+	deploy, err := upDeployment(&service, deployment)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot deploy service: " + err.Error()})
+		return
+	}
+	runDeployment(c, &service, deployment, deploy)
+}
 
+// upDeployment returns the work of deploying the current configuration of a
+// service, recorded on deployment.
+func upDeployment(service *models.Service, deployment *models.Deployment) (jobs.Func, error) {
 	switch service.Type {
 	case "container":
-		c.JSON(http.StatusNotImplemented, gin.H{"message": "Container start not implemented yet"})
+		env, err := environmentVariableList(service.EnvironmentID)
+		if err != nil {
+			return nil, fmt.Errorf("load environment variables: %w", err)
+		}
+		return func(ctx context.Context, out io.Writer) error {
+			return deployContainer(ctx, service, deployment, service.Image, service.Spec, env, out)
+		}, nil
 	case "compose":
-		cmd := exec.Command("docker-compose", "-f", service.ComposePath, "up", "-d")
-		cmd.Dir = filepath.Dir(service.ComposePath)
-		output, err := cmd.CombinedOutput()
+		project, err := snapshotComposeProject(service, deployment)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run docker-compose up", "output": string(output)})
-			return
+			return nil, fmt.Errorf("invalid compose file: %w", err)
 		}
+		return func(ctx context.Context, out io.Writer) error {
+			if err := syncComposeSubServices(database.DB, service, project.File); err != nil {
+				return fmt.Errorf("update sub-services: %w", err)
+			}
+			engine, err := composeEngine(ctx, service)
+			if err != nil {
+				return err
+			}
+			if err := engine.Up(ctx, project, out); err != nil {
+				return err
+			}
+			return recordComposeState(ctx, project, deployment)
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid service type %q", service.Type)
 	}
 }
 
@@ -104,22 +311,41 @@ func DownService(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
 	}
-
-	if service.Type != "compose" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Down operation is only for compose services"})
+	if service.ParentServiceID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-services are managed by their parent compose service"})
 		return
 	}
 
-	cmd := exec.Command("docker-compose", "-f", service.ComposePath, "down")
-	if err := cmd.Run(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bring down service: " + err.Error()})
+	switch service.Type {
+	case "container":
+		if service.ContainerID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Service has no running container"})
+			return
+		}
+		if err := stopContainerService(context.Background(), &service); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bring down service: " + err.Error()})
+			return
+		}
+	case "compose":
+		// The stack is torn down by its labels, so it can be removed even if the compose file is gone.
+		project, err := loadComposeProject(&service)
+		if err != nil {
+			project = &compose.Project{Name: composeProjectName(&service)}
+		}
+		var output bytes.Buffer
+		if err := compose.NewEngine(DockerClient).Down(context.Background(), project, false, &output); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bring down service: " + err.Error(), "output": output.String()})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service type"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Service brought down successfully"})
 }
 
-// ScaleService scales a specific service within a compose stack.
+// ScaleService scales a specific service within a compose stack in a background job.
 func ScaleService(c *gin.Context) {
 	type ScaleRequest struct {
 		SubServiceName string `json:"sub_service_name"`
@@ -145,14 +371,260 @@ func ScaleService(c *gin.Context) {
 		return
 	}
 
-	scaleArg := fmt.Sprintf("%s=%d", request.SubServiceName, request.Replicas)
-	cmd := exec.Command("docker", "compose", "-f", service.ComposePath, "up", "-d", "--scale", scaleArg)
-
-	output, err := cmd.CombinedOutput()
+	deployment := newDeployment(c, &service, models.DeploymentScale)
+	project, err := snapshotComposeProject(&service, deployment)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to scale service: %s", string(output))})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid compose file: " + err.Error()})
+		return
+	}
+	if _, ok := project.File.Services[request.SubServiceName]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Service %q is not defined in the compose file", request.SubServiceName)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Service %s scaled to %d replicas", request.SubServiceName, request.Replicas)})
+	runDeployment(c, &service, deployment, func(ctx context.Context, out io.Writer) error {
+		engine, err := composeEngine(ctx, &service)
+		if err != nil {
+			return err
+		}
+		if err := engine.Scale(ctx, project, request.SubServiceName, request.Replicas, out); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Service %s scaled to %d replicas\n", request.SubServiceName, request.Replicas)
+		return recordComposeState(ctx, project, deployment)
+	})
+}
+
+// composeProjectName returns the project name the resources of a compose service are labelled with.
+func composeProjectName(service *models.Service) string {
+	if service.ComposeProject != "" {
+		return service.ComposeProject
+	}
+	return strings.ToLower(containerName(service))
+}
+
+// composeEngine returns an engine deploying a compose service into the
+// network of its environment, pulling its images with the registry
+// credentials of the service's project and labelling its containers as
+// owned by the service.
+func composeEngine(ctx context.Context, service *models.Service) (*compose.Engine, error) {
+	network, err := ensureEnvironmentNetwork(ctx, service.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := ownershipLabels(service)
+	if err != nil {
+		return nil, err
+	}
+	engine := compose.NewEngine(DockerClient)
+	engine.RegistryAuth = serviceRegistryAuth(service)
+	engine.Network = network
+	engine.Labels = labels
+	return engine, nil
+}
+
+// loadComposeProject parses the compose file of a compose service, with
+// variables interpolated from the service's environment.
+func loadComposeProject(service *models.Service) (*compose.Project, error) {
+	env, err := environmentVariableList(service.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := compose.Load(service.ComposePath, env)
+	if err != nil {
+		return nil, err
+	}
+	return &compose.Project{
+		Name:       composeProjectName(service),
+		WorkingDir: filepath.Dir(service.ComposePath),
+		File:       file,
+	}, nil
+}
+
+// syncComposeSubServices makes the sub-services of a compose service match the
+// services declared in its compose file: missing ones are created, existing
+// ones are updated and ones no longer in the file are deleted.
+func syncComposeSubServices(tx *gorm.DB, parent *models.Service, file *compose.File) error {
+	var existing []models.Service
+	if err := tx.Where("parent_service_id = ?", parent.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	byName := make(map[string]models.Service, len(existing))
+	for _, subService := range existing {
+		byName[subService.Name] = subService
+	}
+
+	parent.SubServices = nil
+	for _, name := range file.ServiceNames() {
+		config := file.Services[name]
+		subService, ok := byName[name]
+		if !ok {
+			subService = models.Service{
+				Name:            name,
+				Type:            "container",
+				EnvironmentID:   parent.EnvironmentID,
+				ParentServiceID: &parent.ID,
+			}
+		}
+		delete(byName, name)
+
+		subService.Image = config.Image
+		subService.Ports = config.Ports
+		subService.Volumes = config.Volumes
+		subService.DependsOn = config.DependsOn
+		if err := tx.Save(&subService).Error; err != nil {
+			return err
+		}
+		parent.SubServices = append(parent.SubServices, subService)
+	}
+
+	for _, stale := range byName {
+		if err := tx.Delete(&stale).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidContainerNameChars matches characters Docker does not accept in container names.
+var invalidContainerNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// containerName returns the Docker container name used for a container service.
+func containerName(service *models.Service) string {
+	return fmt.Sprintf("dockman-%d-%s", service.ID, invalidContainerNameChars.ReplaceAllString(service.Name, "-"))
+}
+
+// ensureImage pulls an image if it is not already present on the host, with
+// the login returned by registryAuth.
+func ensureImage(ctx context.Context, image string, registryAuth func(image string) (string, error)) error {
+	if _, _, err := DockerClient.ImageInspectWithRaw(ctx, image); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	login, err := registryAuth(image)
+	if err != nil {
+		return fmt.Errorf("resolve registry credentials: %w", err)
+	}
+	reader, err := DockerClient.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: login})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// The pull only completes once the progress stream has been consumed.
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
+
+// envHashLabel is the container label holding the hash of the environment
+// variables the container was created with.
+const envHashLabel = "dockman.env-hash"
+
+// withLabel returns a copy of labels with key set to value.
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	labels = maps.Clone(labels)
+	labels[key] = value
+	return labels
+}
+
+// serviceHostname returns the name other services of the same environment
+// reach a container service by.
+func serviceHostname(service *models.Service) string {
+	return strings.ToLower(invalidContainerNameChars.ReplaceAllString(service.Name, "-"))
+}
+
+// startContainerService starts the container backing a container service,
+// creating it from image and spec with the env variables first if it does
+// not exist yet or was created from a different image, spec or variables or
+// without the labels recording its owner.
+// The container joins the network of the service's environment.
+// The resulting container ID is persisted on the service.
+func startContainerService(ctx context.Context, service *models.Service, image string, spec *models.ContainerSpec, env []string) error {
+	labels, err := ownershipLabels(service)
+	if err != nil {
+		return err
+	}
+	if hash := specHash(spec); hash != "" {
+		labels[specHashLabel] = hash
+	}
+	// The digest of the variables is keyed, so it is not compared as is but
+	// checked against the current variables.
+	envHash, err := environmentHash(env)
+	if err != nil {
+		return err
+	}
+	config, hostConfig, err := containerConfig(image, spec, env, withLabel(labels, envHashLabel, envHash))
+	if err != nil {
+		return err
+	}
+	envNetwork, err := ensureEnvironmentNetwork(ctx, service.EnvironmentID)
+	if err != nil {
+		return err
+	}
+	endpoint := &network.EndpointSettings{Aliases: []string{serviceHostname(service)}}
+
+	if service.ContainerID != "" {
+		inspect, err := DockerClient.ContainerInspect(ctx, service.ContainerID)
+		switch {
+		case err == nil && inspect.Config != nil && inspect.Config.Image == image && hasLabels(inspect.Config.Labels, labels) &&
+			inspect.Config.Labels[specHashLabel] == labels[specHashLabel] && environmentMatches(env, inspect.Config.Labels[envHashLabel]):
+			// Containers created before environments had a network join it now.
+			if envNetwork != "" && (inspect.NetworkSettings == nil || inspect.NetworkSettings.Networks[envNetwork] == nil) {
+				if err := DockerClient.NetworkConnect(ctx, envNetwork, service.ContainerID, endpoint); err != nil {
+					return err
+				}
+			}
+			return DockerClient.ContainerStart(ctx, service.ContainerID, container.StartOptions{})
+		case err == nil:
+			// The image, spec, variables or labels changed since the container was created, recreate it so it picks them up.
+			if err := DockerClient.ContainerRemove(ctx, service.ContainerID, container.RemoveOptions{Force: true}); err != nil {
+				return err
+			}
+		case !client.IsErrNotFound(err):
+			return err
+		}
+		// Otherwise the container was removed outside of DockMan, create a new one.
+	}
+
+	if err := ensureImage(ctx, image, serviceRegistryAuth(service)); err != nil {
+		return fmt.Errorf("pull image %s: %w", image, err)
+	}
+
+	var networking *network.NetworkingConfig
+	if envNetwork != "" {
+		networking = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{envNetwork: endpoint}}
+	}
+	resp, err := DockerClient.ContainerCreate(ctx, config, hostConfig, networking, nil, containerName(service))
+	if err != nil {
+		return err
+	}
+
+	// Record the ID before starting so a failed start can still be cleaned up with DownService.
+	service.ContainerID = resp.ID
+	if err := database.DB.Model(service).Update("container_id", resp.ID).Error; err != nil {
+		return err
+	}
+
+	return DockerClient.ContainerStart(ctx, resp.ID, container.StartOptions{})
+}
+
+// stopContainerService stops and removes the container backing a container
+// service, clears the container ID stored on the service and marks it as
+// removed. Events for the container that arrive later no longer match it.
+func stopContainerService(ctx context.Context, service *models.Service) error {
+	timeout := 10
+	if err := DockerClient.ContainerStop(ctx, service.ContainerID, container.StopOptions{Timeout: &timeout}); err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+	if err := DockerClient.ContainerRemove(ctx, service.ContainerID, container.RemoveOptions{}); err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+
+	service.ContainerID = ""
+	service.Status = models.ServiceRemoved
+	service.Health = ""
+	updates := serviceStatus(service.Status, service.Health, time.Now())
+	updates["container_id"] = ""
+	return database.DB.Model(service).Updates(updates).Error
 }
@@ -0,0 +1,199 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/jobs"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForJob waits for the background job started by a request to finish and returns it.
+func waitForJob(t *testing.T, w *httptest.ResponseRecorder) models.Job {
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var response struct {
+		Job models.Job `json:"job"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	jobs.Wait(response.Job.ID)
+
+	var job models.Job
+	require.NoError(t, database.DB.First(&job, response.Job.ID).Error)
+	return job
+}
+
+func TestUpServiceCreatesContainer(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/services/:id/up", UpService)
+
+	service := models.Service{Name: "web app", Type: "container", Image: "nginx:latest", EnvironmentID: 1}
+	database.DB.Create(&service)
+
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "nginx:latest").Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(config *container.Config) bool {
+		return config.Image == "nginx:latest" && config.Labels[serviceLabel] == "1" && config.Labels[environmentLabel] == "1"
+	}), mock.Anything, mock.Anything, mock.Anything, "dockman-1-web-app").Return(container.CreateResponse{ID: "new-container"}, nil)
+	mockClient.On("ContainerStart", mock.Anything, "new-container", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/services/1/up", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	job := waitForJob(t, w)
+	assert.Equal(t, models.JobSucceeded, job.Status)
+	assert.Contains(t, job.Output, "dockman-1-web-app")
+	var stored models.Service
+	database.DB.First(&stored, service.ID)
+	assert.Equal(t, "new-container", stored.ContainerID)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDownServiceRemovesContainer(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/services/:id/down", DownService)
+
+	service := models.Service{Name: "web", Type: "container", Image: "nginx:latest", ContainerID: "running-container", EnvironmentID: 1}
+	database.DB.Create(&service)
+
+	mockClient.On("ContainerStop", mock.Anything, "running-container", mock.Anything).Return(nil)
+	mockClient.On("ContainerRemove", mock.Anything, "running-container", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/services/1/down", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stored models.Service
+	database.DB.First(&stored, service.ID)
+	assert.Empty(t, stored.ContainerID)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpServiceInjectsEnvironmentVariables(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/services/:id/up", UpService)
+
+	database.DB.Create(&models.EnvironmentVariable{Key: "DATABASE_URL", Value: "postgres://db", EnvironmentID: 1})
+	database.DB.Create(&models.EnvironmentVariable{Key: "API_TOKEN", Value: "secret", EnvironmentID: 1})
+	database.DB.Create(&models.EnvironmentVariable{Key: "OTHER", Value: "ignored", EnvironmentID: 2})
+	service := models.Service{Name: "api", Type: "container", Image: "api:latest", EnvironmentID: 1}
+	database.DB.Create(&service)
+
+	mockClient.On("ImageInspectWithRaw", mock.Anything, "api:latest").Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(config *container.Config) bool {
+		return assert.ObjectsAreEqual([]string{"API_TOKEN=secret", "DATABASE_URL=postgres://db"}, config.Env) &&
+			config.Labels[envHashLabel] != ""
+	}), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(container.CreateResponse{ID: "api-container"}, nil)
+	mockClient.On("ContainerStart", mock.Anything, "api-container", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/services/1/up", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	job := waitForJob(t, w)
+	assert.Equal(t, models.JobSucceeded, job.Status)
+	mockClient.AssertExpectations(t)
+}
+
+func TestCreateComposeServiceCreatesSubServices(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/environments/:id/services", CreateService)
+
+	composePath := filepath.Join(t.TempDir(), "docker-compose.yml")
+	os.WriteFile(composePath, []byte(`
+services:
+  web:
+    image: nginx:latest
+    ports: ["8080:80"]
+    depends_on: [db]
+  db:
+    image: postgres:16
+    volumes: ["data:/var/lib/postgresql/data"]
+volumes:
+  data: {}
+`), 0o644)
+
+	body := `{"name": "stack", "type": "compose", "compose_path": "` + composePath + `"}`
+	req, _ := http.NewRequest("POST", "/api/environments/1/services", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var subServices []models.Service
+	database.DB.Where("parent_service_id IS NOT NULL").Order("name").Find(&subServices)
+	if assert.Len(t, subServices, 2) {
+		assert.Equal(t, "db", subServices[0].Name)
+		assert.Equal(t, []string{"data:/var/lib/postgresql/data"}, subServices[0].Volumes)
+		assert.Equal(t, "web", subServices[1].Name)
+		assert.Equal(t, "nginx:latest", subServices[1].Image)
+		assert.Equal(t, []string{"8080:80"}, subServices[1].Ports)
+		assert.Equal(t, []string{"db"}, subServices[1].DependsOn)
+		assert.Equal(t, uint(1), subServices[1].EnvironmentID)
+	}
+}
+
+func TestCreateServiceIgnoresManagedFields(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/environments/:id/services", CreateService)
+
+	w := doRequest(router, "POST", "/api/environments/1/services", `{"name": "web", "type": "container", "image": "nginx",
+		"container_id": "foreign", "status": "running", "health": "healthy", "compose_project": "other", "parent_service_id": 7}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var service models.Service
+	require.NoError(t, database.DB.First(&service).Error)
+	assert.Empty(t, service.ContainerID)
+	assert.Empty(t, service.Status)
+	assert.Empty(t, service.Health)
+	assert.Empty(t, service.ComposeProject)
+	assert.Nil(t, service.ParentServiceID)
+}
+
+func TestRenamingComposeServiceKeepsItsProject(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/environments/:id/services", CreateService)
+	router.PUT("/api/services/:id", UpdateService)
+	composePath := filepath.Join(t.TempDir(), "docker-compose.yml")
+	os.WriteFile(composePath, []byte("services:\n  web:\n    image: nginx:latest\n"), 0644)
+
+	w := doRequest(router, "POST", "/api/environments/1/services", `{"name": "stack", "type": "compose", "compose_path": "`+composePath+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(router, "PUT", "/api/services/1", `{"name": "shop", "compose_path": "`+composePath+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var service models.Service
+	require.NoError(t, database.DB.First(&service, 1).Error)
+	assert.Equal(t, "shop", service.Name)
+	assert.Equal(t, "dockman-1-stack", service.ComposeProject)
+
+	legacy := models.Service{Name: "blog", Type: "compose", ComposePath: composePath, EnvironmentID: 1}
+	require.NoError(t, database.DB.Create(&legacy).Error)
+	w = doRequest(router, "PUT", fmt.Sprintf("/api/services/%d", legacy.ID), `{"name": "news", "compose_path": "`+composePath+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, database.DB.First(&legacy, legacy.ID).Error)
+	assert.Equal(t, fmt.Sprintf("dockman-%d-blog", legacy.ID), legacy.ComposeProject)
+}
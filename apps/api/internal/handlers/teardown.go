@@ -0,0 +1,333 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-manager/api/internal/compose"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// resourceRef identifies a database record removed by a teardown.
+type resourceRef struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// dockerRef identifies a Docker resource removed by a teardown.
+type dockerRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// teardown lists everything deleting projects, environments or services
+// removes, from the Docker host and from the database. The same list is
+// returned by a dry run and removed by the actual deletion.
+type teardown struct {
+	DryRun bool `json:"dry_run"`
+
+	// --- Docker host ---
+	Containers []dockerRef `json:"containers"`
+	Networks   []dockerRef `json:"networks"`
+	// Named volumes of compose stacks, only removed on request.
+	Volumes []dockerRef `json:"volumes"`
+
+	// --- Database ---
+	Projects            []resourceRef `json:"projects"`
+	Environments        []resourceRef `json:"environments"`
+	Services            []serviceRef  `json:"services"`
+	Variables           []resourceRef `json:"variables"`
+	RegistryCredentials []resourceRef `json:"registry_credentials"`
+	// Members is the number of project and environment role grants removed.
+	Members int `json:"members"`
+
+	projectIDs, environmentIDs, serviceIDs, variableIDs, credentialIDs []uint
+	projectMemberIDs, environmentMemberIDs                             []uint
+}
+
+// planTeardown lists what deleting the given projects, environments and
+// services removes: the environments of the projects, the services of the
+// environments and the sub-services of the services, with their variables,
+// members and registry credentials, and the containers, networks and, if
+// removeVolumes is set, the compose volumes they own on the Docker host.
+func planTeardown(ctx context.Context, projectIDs, environmentIDs, serviceIDs []uint, removeVolumes bool) (*teardown, error) {
+	plan := &teardown{
+		Containers: []dockerRef{}, Networks: []dockerRef{}, Volumes: []dockerRef{},
+		Projects: []resourceRef{}, Environments: []resourceRef{}, Services: []serviceRef{},
+		Variables: []resourceRef{}, RegistryCredentials: []resourceRef{},
+	}
+
+	// --- Database records ---
+	var projects []models.Project
+	if len(projectIDs) > 0 {
+		if err := database.DB.Order("id").Find(&projects, projectIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, project := range projects {
+		plan.projectIDs = append(plan.projectIDs, project.ID)
+		plan.Projects = append(plan.Projects, resourceRef{ID: project.ID, Name: project.Name})
+	}
+
+	var environments []models.Environment
+	query := database.DB.Order("id")
+	switch {
+	case len(plan.projectIDs) > 0:
+		query = query.Where("project_id IN ?", plan.projectIDs)
+	case len(environmentIDs) > 0:
+		query = query.Where("id IN ?", environmentIDs)
+	default:
+		query = nil
+	}
+	if query != nil {
+		if err := query.Find(&environments).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, environment := range environments {
+		plan.environmentIDs = append(plan.environmentIDs, environment.ID)
+		plan.Environments = append(plan.Environments, resourceRef{ID: environment.ID, Name: environment.Name})
+	}
+
+	var services []models.Service
+	query = database.DB.Order("id")
+	switch {
+	case len(plan.environmentIDs) > 0:
+		query = query.Where("environment_id IN ?", plan.environmentIDs)
+	case len(serviceIDs) > 0:
+		query = query.Where("id IN ? OR parent_service_id IN ?", serviceIDs, serviceIDs)
+	default:
+		query = nil
+	}
+	if query != nil {
+		if err := query.Find(&services).Error; err != nil {
+			return nil, err
+		}
+	}
+	containerIDs := map[string]bool{}
+	composeProjects := map[string]bool{}
+	for i := range services {
+		service := &services[i]
+		plan.serviceIDs = append(plan.serviceIDs, service.ID)
+		plan.Services = append(plan.Services, serviceRef{ID: service.ID, Name: service.Name, EnvironmentID: service.EnvironmentID})
+		if service.ContainerID != "" {
+			containerIDs[service.ContainerID] = true
+		}
+		if service.Type == "compose" {
+			composeProjects[composeProjectName(service)] = true
+		}
+	}
+
+	if len(plan.environmentIDs) > 0 {
+		var variables []models.EnvironmentVariable
+		// Only keys are listed, values stay encrypted.
+		raw := database.DB.Session(&gorm.Session{SkipHooks: true})
+		if err := raw.Select("id", "key").Where("environment_id IN ?", plan.environmentIDs).Order("id").Find(&variables).Error; err != nil {
+			return nil, err
+		}
+		for _, variable := range variables {
+			plan.variableIDs = append(plan.variableIDs, variable.ID)
+			plan.Variables = append(plan.Variables, resourceRef{ID: variable.ID, Name: variable.Key})
+		}
+		if err := database.DB.Model(&models.EnvironmentMember{}).Where("environment_id IN ?", plan.environmentIDs).Pluck("id", &plan.environmentMemberIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(plan.projectIDs) > 0 {
+		var credentials []models.RegistryCredential
+		raw := database.DB.Session(&gorm.Session{SkipHooks: true})
+		if err := raw.Select("id", "registry").Where("project_id IN ?", plan.projectIDs).Order("id").Find(&credentials).Error; err != nil {
+			return nil, err
+		}
+		for _, credential := range credentials {
+			plan.credentialIDs = append(plan.credentialIDs, credential.ID)
+			plan.RegistryCredentials = append(plan.RegistryCredentials, resourceRef{ID: credential.ID, Name: credential.Registry})
+		}
+		if err := database.DB.Model(&models.ProjectMember{}).Where("project_id IN ?", plan.projectIDs).Pluck("id", &plan.projectMemberIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+	plan.Members = len(plan.projectMemberIDs) + len(plan.environmentMemberIDs)
+
+	// --- Docker resources ---
+	// Resources are matched by the labels DockMan sets, and by the container
+	// ID or compose project of services adopted without them.
+	environmentSet := idSet(plan.environmentIDs)
+	serviceSet := idSet(plan.serviceIDs)
+	containers, err := DockerClient.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("list containers: %w", err)
+	}
+	for _, ctr := range containers {
+		if containerIDs[ctr.ID] || serviceSet[ctr.Labels[serviceLabel]] || environmentSet[ctr.Labels[environmentLabel]] ||
+			composeProjects[ctr.Labels[compose.ProjectLabel]] {
+			ref := dockerRef{ID: ctr.ID, Name: ctr.ID}
+			if len(ctr.Names) > 0 {
+				ref.Name = strings.TrimPrefix(ctr.Names[0], "/")
+			}
+			plan.Containers = append(plan.Containers, ref)
+		}
+	}
+
+	networks, err := DockerClient.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list networks: %w", err)
+	}
+	environmentNetworks := map[string]bool{}
+	for _, id := range plan.environmentIDs {
+		environmentNetworks[environmentNetworkName(id)] = true
+	}
+	for _, n := range networks {
+		if environmentNetworks[n.Name] || environmentSet[n.Labels[environmentLabel]] || composeProjects[n.Labels[compose.ProjectLabel]] {
+			plan.Networks = append(plan.Networks, dockerRef{ID: n.ID, Name: n.Name})
+		}
+	}
+
+	if removeVolumes && len(composeProjects) > 0 {
+		volumes, err := DockerClient.VolumeList(ctx, volume.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("list volumes: %w", err)
+		}
+		for _, v := range volumes.Volumes {
+			if composeProjects[v.Labels[compose.ProjectLabel]] {
+				plan.Volumes = append(plan.Volumes, dockerRef{ID: v.Name, Name: v.Name})
+			}
+		}
+	}
+	return plan, nil
+}
+
+// idSet returns the IDs as the strings labels hold them as.
+func idSet(ids []uint) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[strconv.FormatUint(uint64(id), 10)] = true
+	}
+	return set
+}
+
+// apply removes the Docker resources of the plan, then deletes its records in
+// a single transaction. Records are only deleted once the Docker host is
+// clean, so a failed teardown can be retried.
+func (t *teardown) apply(ctx context.Context) error {
+	timeout := 10
+	for _, ctr := range t.Containers {
+		if err := DockerClient.ContainerStop(ctx, ctr.ID, container.StopOptions{Timeout: &timeout}); err != nil && !client.IsErrNotFound(err) {
+			return fmt.Errorf("stop container %s: %w", ctr.Name, err)
+		}
+		if err := DockerClient.ContainerRemove(ctx, ctr.ID, container.RemoveOptions{}); err != nil && !client.IsErrNotFound(err) {
+			return fmt.Errorf("remove container %s: %w", ctr.Name, err)
+		}
+	}
+	for _, n := range t.Networks {
+		if err := DockerClient.NetworkRemove(ctx, n.ID); err != nil && !client.IsErrNotFound(err) {
+			return fmt.Errorf("remove network %s: %w", n.Name, err)
+		}
+	}
+	for _, v := range t.Volumes {
+		if err := DockerClient.VolumeRemove(ctx, v.Name, false); err != nil && !client.IsErrNotFound(err) {
+			return fmt.Errorf("remove volume %s: %w", v.Name, err)
+		}
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		deletions := []struct {
+			model interface{}
+			ids   []uint
+		}{
+			{&models.Service{}, t.serviceIDs},
+			{&models.EnvironmentMember{}, t.environmentMemberIDs},
+			{&models.Environment{}, t.environmentIDs},
+			{&models.ProjectMember{}, t.projectMemberIDs},
+			{&models.Project{}, t.projectIDs},
+		}
+		for _, deletion := range deletions {
+			if len(deletion.ids) == 0 {
+				continue
+			}
+			if err := tx.Delete(deletion.model, deletion.ids).Error; err != nil {
+				return err
+			}
+		}
+
+		// Variables, registry credentials and the environment snapshots of
+		// deployments hold secrets, and jobs the output of those deployments:
+		// none of them are kept once deleted.
+		if len(t.variableIDs) > 0 {
+			if err := tx.Unscoped().Delete(&models.EnvironmentVariable{}, t.variableIDs).Error; err != nil {
+				return err
+			}
+		}
+		if len(t.credentialIDs) > 0 {
+			if err := tx.Unscoped().Delete(&models.RegistryCredential{}, t.credentialIDs).Error; err != nil {
+				return err
+			}
+		}
+		if len(t.serviceIDs) > 0 {
+			if err := tx.Unscoped().Where("service_id IN ?", t.serviceIDs).Delete(&models.Job{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("service_id IN ?", t.serviceIDs).Delete(&models.Deployment{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// runTeardown responds with what deleting removes when the dry_run query
+// parameter is "true", and deletes it otherwise. Deletion is refused while a
+// deployment of one of the services is in progress.
+func runTeardown(c *gin.Context, projectIDs, environmentIDs, serviceIDs []uint) {
+	ctx := c.Request.Context()
+	plan, err := planTeardown(ctx, projectIDs, environmentIDs, serviceIDs, c.Query("volumes") == "true")
+	if err != nil {
+		log.Printf("Error planning teardown: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list the resources to remove: " + err.Error()})
+		return
+	}
+	if c.Query("dry_run") == "true" {
+		plan.DryRun = true
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
+	if len(plan.serviceIDs) > 0 {
+		var running int64
+		err := database.DB.Model(&models.Job{}).
+			Where("service_id IN ? AND status IN ?", plan.serviceIDs, []string{models.JobQueued, models.JobRunning}).
+			Count(&running).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for running jobs"})
+			return
+		}
+		if running > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A deployment is in progress, cancel it or wait for it to finish"})
+			return
+		}
+	}
+
+	if err := plan.apply(ctx); err != nil {
+		log.Printf("Error tearing down resources: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
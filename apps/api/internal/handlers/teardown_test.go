@@ -0,0 +1,271 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"docker-manager/api/internal/compose"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// teardownFixture is a project with two environments: prod, with a container
+// service, a compose stack and a variable, and staging, with one service.
+type teardownFixture struct {
+	project        models.Project
+	prod, staging  models.Environment
+	web, stack     models.Service
+	worker, canary models.Service
+}
+
+func newTeardownFixture(t *testing.T, mockClient *MockDockerClient) *teardownFixture {
+	f := &teardownFixture{project: models.Project{Name: "shop"}}
+	require.NoError(t, database.DB.Create(&f.project).Error)
+	f.prod = models.Environment{Name: "prod", ProjectID: f.project.ID}
+	f.staging = models.Environment{Name: "staging", ProjectID: f.project.ID}
+	require.NoError(t, database.DB.Create(&[]*models.Environment{&f.prod, &f.staging}).Error)
+	f.web = models.Service{Name: "web", Type: "container", Image: "nginx", ContainerID: "web-1", EnvironmentID: f.prod.ID}
+	f.stack = models.Service{Name: "stack", Type: "compose", ComposePath: "/srv/stack/compose.yaml", EnvironmentID: f.prod.ID}
+	f.canary = models.Service{Name: "canary", Type: "container", Image: "nginx", EnvironmentID: f.staging.ID}
+	require.NoError(t, database.DB.Create(&[]*models.Service{&f.web, &f.stack, &f.canary}).Error)
+	f.worker = models.Service{Name: "worker", Type: "container", Image: "worker", EnvironmentID: f.prod.ID, ParentServiceID: &f.stack.ID}
+	require.NoError(t, database.DB.Create(&f.worker).Error)
+	require.NoError(t, database.DB.Create(&models.EnvironmentVariable{Key: "TOKEN", Value: "secret", EnvironmentID: f.prod.ID}).Error)
+	require.NoError(t, database.DB.Create(&models.EnvironmentVariable{Key: "DEBUG", Value: "1", EnvironmentID: f.staging.ID}).Error)
+
+	stackProject := composeProjectName(&f.stack)
+	id := func(n uint) string { return fmt.Sprint(n) }
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		// Adopted without labels, matched by its ID
+		{ID: "web-1", Names: []string{"/web"}},
+		{ID: "worker-1", Names: []string{"/stack-worker-1"}, Labels: map[string]string{serviceLabel: id(f.stack.ID), environmentLabel: id(f.prod.ID), compose.ProjectLabel: stackProject}},
+		{ID: "canary-1", Names: []string{"/canary"}, Labels: map[string]string{serviceLabel: id(f.canary.ID), environmentLabel: id(f.staging.ID)}},
+		{ID: "unmanaged", Names: []string{"/postgres"}},
+	}, nil)
+	mockClient.On("NetworkList", mock.Anything, mock.Anything).Return([]types.NetworkResource{
+		{ID: "net-prod", Name: environmentNetworkName(f.prod.ID), Labels: map[string]string{environmentLabel: id(f.prod.ID)}},
+		{ID: "net-staging", Name: environmentNetworkName(f.staging.ID), Labels: map[string]string{environmentLabel: id(f.staging.ID)}},
+		{ID: "net-stack", Name: stackProject + "_default", Labels: map[string]string{compose.ProjectLabel: stackProject}},
+		{ID: "bridge", Name: "bridge"},
+	}, nil)
+	mockClient.On("VolumeList", mock.Anything, mock.Anything).Return(volume.ListResponse{Volumes: []*volume.Volume{
+		{Name: stackProject + "_data", Labels: map[string]string{compose.ProjectLabel: stackProject}},
+		{Name: "unmanaged"},
+	}}, nil).Maybe()
+	return f
+}
+
+func decodeTeardown(t *testing.T, body []byte) (containers, networks, volumes, services, variables []string) {
+	var plan teardown
+	require.NoError(t, json.Unmarshal(body, &plan))
+	for _, c := range plan.Containers {
+		containers = append(containers, c.ID)
+	}
+	for _, n := range plan.Networks {
+		networks = append(networks, n.ID)
+	}
+	for _, v := range plan.Volumes {
+		volumes = append(volumes, v.Name)
+	}
+	for _, s := range plan.Services {
+		services = append(services, s.Name)
+	}
+	for _, v := range plan.Variables {
+		variables = append(variables, v.Name)
+	}
+	return
+}
+
+func TestDeleteEnvironmentDryRunRemovesNothing(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.DELETE("/api/environments/:id", DeleteEnvironment)
+	f := newTeardownFixture(t, mockClient)
+
+	w := doRequest(router, "DELETE", fmt.Sprintf("/api/environments/%d?dry_run=true&volumes=true", f.prod.ID), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	containers, networks, volumes, services, variables := decodeTeardown(t, w.Body.Bytes())
+	assert.Equal(t, []string{"web-1", "worker-1"}, containers)
+	assert.Equal(t, []string{"net-prod", "net-stack"}, networks)
+	assert.Equal(t, []string{composeProjectName(&f.stack) + "_data"}, volumes)
+	assert.Equal(t, []string{"web", "stack", "worker"}, services)
+	assert.Equal(t, []string{"TOKEN"}, variables)
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	mockClient.AssertNotCalled(t, "ContainerRemove", mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "NetworkRemove", mock.Anything, mock.Anything)
+	var count int64
+	database.DB.Model(&models.Service{}).Count(&count)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestDeleteEnvironmentTearsDownItsResources(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.DELETE("/api/environments/:id", DeleteEnvironment)
+	f := newTeardownFixture(t, mockClient)
+	for _, id := range []string{"web-1", "worker-1"} {
+		mockClient.On("ContainerStop", mock.Anything, id, mock.Anything).Return(nil).Once()
+		mockClient.On("ContainerRemove", mock.Anything, id, mock.Anything).Return(nil).Once()
+	}
+	mockClient.On("NetworkRemove", mock.Anything, "net-prod").Return(nil).Once()
+	mockClient.On("NetworkRemove", mock.Anything, "net-stack").Return(nil).Once()
+	deployment := models.Deployment{ServiceID: f.web.ID, Kind: "deploy", Status: models.DeploymentSucceeded}
+	require.NoError(t, deployment.SetEnvironment([]string{"TOKEN=secret"}, "hash"))
+	require.NoError(t, database.DB.Create(&deployment).Error)
+
+	w := doRequest(router, "DELETE", fmt.Sprintf("/api/environments/%d", f.prod.ID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	mockClient.AssertExpectations(t)
+	// Volumes are kept unless asked for.
+	mockClient.AssertNotCalled(t, "VolumeRemove", mock.Anything, mock.Anything, mock.Anything)
+
+	var services []models.Service
+	require.NoError(t, database.DB.Order("id").Find(&services).Error)
+	require.Len(t, services, 1)
+	assert.Equal(t, "canary", services[0].Name)
+	// Secrets are deleted for good, not soft-deleted.
+	var variables []models.EnvironmentVariable
+	require.NoError(t, database.DB.Unscoped().Find(&variables).Error)
+	require.Len(t, variables, 1)
+	assert.Equal(t, "DEBUG", variables[0].Key)
+	assert.Error(t, database.DB.First(&models.Environment{}, f.prod.ID).Error)
+	assert.NoError(t, database.DB.First(&models.Environment{}, f.staging.ID).Error)
+	var deployments int64
+	require.NoError(t, database.DB.Unscoped().Model(&models.Deployment{}).Count(&deployments).Error)
+	assert.Zero(t, deployments)
+}
+
+func TestDeleteProjectRemovesEverything(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.DELETE("/api/projects/:id", DeleteProject)
+	f := newTeardownFixture(t, mockClient)
+	require.NoError(t, database.DB.Create(&models.RegistryCredential{Registry: "ghcr.io", ProjectID: &f.project.ID, Username: "ci", Password: "token"}).Error)
+	require.NoError(t, database.DB.Create(&models.ProjectMember{ProjectID: f.project.ID, UserID: 1, Role: models.RoleAdmin}).Error)
+	other := models.Project{Name: "blog"}
+	require.NoError(t, database.DB.Create(&other).Error)
+	mockClient.On("ContainerStop", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockClient.On("ContainerRemove", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockClient.On("NetworkRemove", mock.Anything, mock.Anything).Return(nil)
+	mockClient.On("VolumeRemove", mock.Anything, composeProjectName(&f.stack)+"_data", false).Return(nil).Once()
+
+	w := doRequest(router, "DELETE", fmt.Sprintf("/api/projects/%d?volumes=true", f.project.ID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	containers, networks, _, services, variables := decodeTeardown(t, w.Body.Bytes())
+	assert.Equal(t, []string{"web-1", "worker-1", "canary-1"}, containers)
+	assert.Equal(t, []string{"net-prod", "net-staging", "net-stack"}, networks)
+	assert.Len(t, services, 4)
+	assert.Equal(t, []string{"TOKEN", "DEBUG"}, variables)
+	mockClient.AssertNotCalled(t, "ContainerRemove", mock.Anything, "unmanaged", mock.Anything)
+	mockClient.AssertExpectations(t)
+
+	for model, want := range map[interface{}]int64{
+		&models.Project{}: 1, &models.Environment{}: 0, &models.Service{}: 0, &models.EnvironmentVariable{}: 0,
+		&models.ProjectMember{}: 0,
+	} {
+		var count int64
+		require.NoError(t, database.DB.Model(model).Count(&count).Error)
+		assert.Equal(t, want, count, "%T", model)
+	}
+	var credentials int64
+	database.DB.Unscoped().Model(&models.RegistryCredential{}).Count(&credentials)
+	assert.Zero(t, credentials)
+}
+
+func TestDeleteServiceRemovesItsSubServices(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.DELETE("/api/services/:id", DeleteService)
+	f := newTeardownFixture(t, mockClient)
+
+	w := doRequest(router, "DELETE", fmt.Sprintf("/api/services/%d", f.worker.ID), "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	require.NoError(t, database.DB.Create(&models.Job{Kind: "deploy", Status: models.JobRunning, ServiceID: &f.stack.ID}).Error)
+	w = doRequest(router, "DELETE", fmt.Sprintf("/api/services/%d", f.stack.ID), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	database.DB.Model(&models.Job{}).Where("service_id = ?", f.stack.ID).Update("status", models.JobSucceeded)
+
+	mockClient.On("ContainerStop", mock.Anything, "worker-1", mock.Anything).Return(nil).Once()
+	mockClient.On("ContainerRemove", mock.Anything, "worker-1", mock.Anything).Return(nil).Once()
+	mockClient.On("NetworkRemove", mock.Anything, "net-stack").Return(nil).Once()
+	w = doRequest(router, "DELETE", fmt.Sprintf("/api/services/%d", f.stack.ID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	containers, networks, _, services, variables := decodeTeardown(t, w.Body.Bytes())
+	assert.Equal(t, []string{"worker-1"}, containers)
+	assert.Equal(t, []string{"net-stack"}, networks)
+	assert.Equal(t, []string{"stack", "worker"}, services)
+	assert.Empty(t, variables)
+	mockClient.AssertExpectations(t)
+
+	var remaining []string
+	database.DB.Model(&models.Service{}).Order("id").Pluck("name", &remaining)
+	assert.Equal(t, []string{"web", "canary"}, remaining)
+	var jobs int64
+	require.NoError(t, database.DB.Unscoped().Model(&models.Job{}).Count(&jobs).Error)
+	assert.Zero(t, jobs)
+}
+
+func TestUpdateService(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.PUT("/api/services/:id", UpdateService)
+	f := newTeardownFixture(t, mockClient)
+	path := fmt.Sprintf("/api/services/%d", f.web.ID)
+
+	w := doRequest(router, "PUT", path, `{"name": "web", "type": "compose", "compose_path": "/srv/compose.yaml"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "The type of a service cannot be changed")
+
+	w = doRequest(router, "PUT", path, `{"name": "web", "image": "nginx", "spec": {"restart_policy": "sometimes"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid container spec")
+
+	w = doRequest(router, "PUT", path, `{"name": "frontend", "image": "nginx:1.27"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var service models.Service
+	require.NoError(t, database.DB.First(&service, f.web.ID).Error)
+	assert.Equal(t, "frontend", service.Name)
+	assert.Equal(t, "nginx:1.27", service.Image)
+	assert.Equal(t, "web-1", service.ContainerID)
+	assert.Equal(t, f.prod.ID, service.EnvironmentID)
+}
+
+func TestCreateProjectAndEnvironmentIgnoreNestedResources(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.POST("/api/projects", CreateProject)
+	router.POST("/api/projects/:id/environments", CreateEnvironment)
+	mockClient.On("NetworkInspect", mock.Anything, mock.Anything, mock.Anything).Return(types.NetworkResource{ID: "net"}, nil).Maybe()
+
+	w := doRequest(router, "POST", "/api/projects", `{"name": "shop", "environments": [{"name": "prod",
+		"services": [{"name": "web", "type": "container", "container_id": "foreign"}],
+		"variables": [{"key": "TOKEN", "value": "x"}]}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doRequest(router, "POST", "/api/projects/1/environments", `{"name": "prod",
+		"services": [{"name": "web", "type": "container", "container_id": "foreign"}],
+		"variables": [{"key": "TOKEN", "value": "x"}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for model, want := range map[interface{}]int64{
+		&models.Project{}: 1, &models.Environment{}: 1, &models.Service{}: 0, &models.EnvironmentVariable{}: 0,
+	} {
+		var count int64
+		require.NoError(t, database.DB.Model(model).Count(&count).Error)
+		assert.Equal(t, want, count, "%T", model)
+	}
+}
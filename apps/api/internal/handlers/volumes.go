@@ -0,0 +1,457 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-manager/api/internal/auth"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/gin-gonic/gin"
+)
+
+// volumeHelperImage is the image of the short-lived containers used to read
+// the files of a volume.
+const volumeHelperImage = "busybox:1.36"
+
+// volumeMountPoint is where helper containers mount the volume they read.
+const volumeMountPoint = "/volume"
+
+// helperLabel marks the short-lived containers DockMan runs for itself.
+const helperLabel = "dockman.helper"
+
+// volumeHelperTimeout bounds how long a helper container may run.
+const volumeHelperTimeout = time.Minute
+
+// serviceRef identifies a service that mounts a volume.
+type serviceRef struct {
+	ID            uint   `json:"id"`
+	Name          string `json:"name"`
+	EnvironmentID uint   `json:"environment_id"`
+}
+
+// volumeInfo is a volume with its disk usage and the services that mount it.
+type volumeInfo struct {
+	volume.Volume
+	Services []serviceRef `json:"Services"`
+}
+
+// volumeEntry is a file or directory in a volume.
+type volumeEntry struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	Type       string    `json:"type"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// volumeUsage returns the disk usage of every volume, by name, as reported
+// by "docker system df".
+func volumeUsage(ctx context.Context) (map[string]*volume.UsageData, error) {
+	usage, err := DockerClient.DiskUsage(ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*volume.UsageData, len(usage.Volumes))
+	for _, v := range usage.Volumes {
+		if v != nil && v.UsageData != nil {
+			result[v.Name] = v.UsageData
+		}
+	}
+	return result, nil
+}
+
+// volumeServices returns the services whose containers mount each volume, by name.
+func volumeServices(ctx context.Context) (map[string][]serviceRef, error) {
+	containers, err := DockerClient.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	result := map[string][]serviceRef{}
+	seen := map[string]bool{}
+	for _, c := range containers {
+		service, err := containerService(c.ID, c.Labels)
+		if errors.Is(err, auth.ErrScopeNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range c.Mounts {
+			key := fmt.Sprintf("%s/%d", m.Name, service.ID)
+			if m.Type != mount.TypeVolume || seen[key] {
+				continue
+			}
+			seen[key] = true
+			result[m.Name] = append(result[m.Name], serviceRef{ID: service.ID, Name: service.Name, EnvironmentID: service.EnvironmentID})
+		}
+	}
+	return result, nil
+}
+
+// describeVolumes adds the disk usage and services of each volume.
+func describeVolumes(ctx context.Context, volumes []*volume.Volume) ([]volumeInfo, error) {
+	usage, err := volumeUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("disk usage: %w", err)
+	}
+	services, err := volumeServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("volume services: %w", err)
+	}
+	result := make([]volumeInfo, 0, len(volumes))
+	for _, v := range volumes {
+		info := volumeInfo{Volume: *v, Services: services[v.Name]}
+		if info.UsageData == nil {
+			info.UsageData = usage[v.Name]
+		}
+		if info.Services == nil {
+			info.Services = []serviceRef{}
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// ListVolumes lists the volumes on the host with their size and the services
+// that mount them.
+func ListVolumes(c *gin.Context) {
+	ctx := c.Request.Context()
+	list, err := DockerClient.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list volumes"})
+		return
+	}
+	volumes, err := describeVolumes(ctx, list.Volumes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to describe volumes: " + err.Error()})
+		return
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	c.JSON(http.StatusOK, volumes)
+}
+
+// ListServiceVolumes lists the volumes mounted by the containers of a service.
+func ListServiceVolumes(c *gin.Context) {
+	serviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	ctx := c.Request.Context()
+	services, err := volumeServices(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list volumes"})
+		return
+	}
+
+	var volumes []*volume.Volume
+	for name, refs := range services {
+		for _, ref := range refs {
+			if ref.ID != uint(serviceID) {
+				continue
+			}
+			v, err := DockerClient.VolumeInspect(ctx, name)
+			if err != nil && !client.IsErrNotFound(err) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to inspect volume"})
+				return
+			}
+			if err == nil {
+				volumes = append(volumes, &v)
+			}
+		}
+	}
+	described, err := describeVolumes(ctx, volumes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to describe volumes: " + err.Error()})
+		return
+	}
+	sort.Slice(described, func(i, j int) bool { return described[i].Name < described[j].Name })
+	c.JSON(http.StatusOK, described)
+}
+
+// GetVolume returns a volume with its size and the services that mount it.
+func GetVolume(c *gin.Context) {
+	ctx := c.Request.Context()
+	v, err := DockerClient.VolumeInspect(ctx, c.Param("name"))
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Volume not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to inspect volume"})
+		return
+	}
+	described, err := describeVolumes(ctx, []*volume.Volume{&v})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to describe volume: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, described[0])
+}
+
+// CreateVolume creates a volume.
+func CreateVolume(c *gin.Context) {
+	var input struct {
+		Name       string            `json:"name" binding:"required"`
+		Driver     string            `json:"driver"`
+		DriverOpts map[string]string `json:"driver_opts"`
+		Labels     map[string]string `json:"labels"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for key := range input.Labels {
+		if strings.HasPrefix(key, "dockman.") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Labels starting with dockman. are reserved"})
+			return
+		}
+	}
+	v, err := DockerClient.VolumeCreate(c.Request.Context(), volume.CreateOptions{
+		Name:       strings.TrimSpace(input.Name),
+		Driver:     input.Driver,
+		DriverOpts: input.DriverOpts,
+		Labels:     input.Labels,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create volume: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// DeleteVolume removes a volume. Volumes mounted by a container are only
+// removed with ?force=true, which Docker still refuses for running containers.
+func DeleteVolume(c *gin.Context) {
+	force := c.Query("force") == "true"
+	if err := DockerClient.VolumeRemove(c.Request.Context(), c.Param("name"), force); err != nil {
+		switch {
+		case client.IsErrNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Volume not found"})
+		default:
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to remove volume: " + err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// volumePath cleans a path inside a volume so it cannot escape the volume.
+func volumePath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// createVolumeHelper creates, without starting it, a helper container that
+// mounts a volume read-only and runs cmd. The caller must remove it.
+func createVolumeHelper(ctx context.Context, volumeName string, cmd []string) (string, error) {
+	if _, err := DockerClient.VolumeInspect(ctx, volumeName); err != nil {
+		return "", err
+	}
+	if err := ensureImage(ctx, volumeHelperImage, func(string) (string, error) { return "", nil }); err != nil {
+		return "", fmt.Errorf("pull helper image: %w", err)
+	}
+	config := &container.Config{
+		Image:  volumeHelperImage,
+		Cmd:    cmd,
+		Labels: map[string]string{helperLabel: "volume-browser"},
+	}
+	hostConfig := &container.HostConfig{
+		NetworkMode: "none",
+		Mounts:      []mount.Mount{{Type: mount.TypeVolume, Source: volumeName, Target: volumeMountPoint, ReadOnly: true}},
+	}
+	resp, err := DockerClient.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// removeVolumeHelper removes a helper container, even after ctx is canceled.
+func removeVolumeHelper(id string) {
+	DockerClient.ContainerRemove(context.Background(), id, container.RemoveOptions{Force: true})
+}
+
+// runVolumeHelper runs cmd in a helper container mounting a volume and
+// returns its output. A non-zero exit status is returned as an error with
+// the command's error output.
+func runVolumeHelper(ctx context.Context, volumeName string, cmd []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, volumeHelperTimeout)
+	defer cancel()
+	id, err := createVolumeHelper(ctx, volumeName, cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer removeVolumeHelper(id)
+
+	if err := DockerClient.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return nil, err
+	}
+	var exitCode int64
+	statusCh, errCh := DockerClient.ContainerWait(ctx, id, container.WaitConditionNotRunning)
+	select {
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	case err := <-errCh:
+		return nil, err
+	}
+
+	logs, err := DockerClient.ContainerLogs(ctx, id, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return nil, err
+	}
+	defer logs.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, logs); err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("exit status %d: %s", exitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// entryTypes maps the file types printed by stat to the types of volumeEntry.
+var entryTypes = map[string]string{
+	"regular file":       "file",
+	"regular empty file": "file",
+	"directory":          "directory",
+	"symbolic link":      "symlink",
+}
+
+// parseVolumeEntries parses the "%F/%s/%Y/%n" stat lines printed by the
+// listing helper. The full path comes last as it is the only field that can
+// contain the separator.
+func parseVolumeEntries(output []byte) []volumeEntry {
+	entries := []volumeEntry{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.SplitN(line, "/", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[1], 10, 64)
+		modified, _ := strconv.ParseInt(fields[2], 10, 64)
+		entryType, ok := entryTypes[fields[0]]
+		if !ok {
+			entryType = "other"
+		}
+		p := volumePath(strings.TrimPrefix(fields[3], volumeMountPoint))
+		entries = append(entries, volumeEntry{
+			Name:       path.Base(p),
+			Path:       p,
+			Type:       entryType,
+			Size:       size,
+			ModifiedAt: time.Unix(modified, 0).UTC(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if (entries[i].Type == "directory") != (entries[j].Type == "directory") {
+			return entries[i].Type == "directory"
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// ListVolumeFiles lists the files of a directory of a volume, given by the
+// path query parameter, through a read-only helper container.
+func ListVolumeFiles(c *gin.Context) {
+	dir := volumePath(c.Query("path"))
+	cmd := []string{"find", volumeMountPoint + dir, "-mindepth", "1", "-maxdepth", "1", "-exec", "stat", "-c", "%F/%s/%Y/%n", "{}", "+"}
+	output, err := runVolumeHelper(c.Request.Context(), c.Param("name"), cmd)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Volume not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to list files: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"path": dir, "entries": parseVolumeEntries(output)})
+}
+
+// DownloadVolumeFile downloads a file of a volume, given by the path query
+// parameter, or a directory as a tar archive.
+func DownloadVolumeFile(c *gin.Context) {
+	file := volumePath(c.Query("path"))
+	ctx, cancel := context.WithTimeout(c.Request.Context(), volumeHelperTimeout)
+	id, err := createVolumeHelper(ctx, c.Param("name"), []string{"true"})
+	cancel()
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Volume not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read volume: " + err.Error()})
+		return
+	}
+	defer removeVolumeHelper(id)
+
+	// The copy streams for as long as the download takes, so the timeout
+	// only bounds waiting for it to start. Files can be copied out of a
+	// container that was never started.
+	ctx, cancel = context.WithCancel(c.Request.Context())
+	defer cancel()
+	timer := time.AfterFunc(volumeHelperTimeout, cancel)
+	reader, stat, err := DockerClient.CopyFromContainer(ctx, id, volumeMountPoint+file)
+	if !timer.Stop() && err == nil {
+		reader.Close()
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file: " + err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	if stat.Mode&os.ModeSymlink != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Symbolic links cannot be downloaded"})
+		return
+	}
+	name := path.Base(file)
+	if file == "/" {
+		name = c.Param("name")
+	}
+	if stat.Mode.IsDir() {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".tar"))
+		c.DataFromReader(http.StatusOK, -1, "application/x-tar", reader, nil)
+		return
+	}
+
+	archive := tar.NewReader(reader)
+	header, err := archive.Next()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file: " + err.Error()})
+		return
+	}
+	if header.Typeflag != tar.TypeReg {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only regular files and directories can be downloaded"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.DataFromReader(http.StatusOK, header.Size, "application/octet-stream", archive, nil)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListVolumesWithUsageAndServices(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.GET("/volumes", ListVolumes)
+	service := models.Service{Name: "db", Type: "container", ContainerID: "db-container", EnvironmentID: 1}
+	require.NoError(t, database.DB.Create(&service).Error)
+
+	mockClient.On("VolumeList", mock.Anything, mock.Anything).Return(volume.ListResponse{Volumes: []*volume.Volume{
+		{Name: "pgdata", Driver: "local"},
+		{Name: "cache", Driver: "local"},
+	}}, nil)
+	mockClient.On("DiskUsage", mock.Anything, mock.Anything).Return(types.DiskUsage{Volumes: []*volume.Volume{
+		{Name: "pgdata", UsageData: &volume.UsageData{Size: 4096, RefCount: 1}},
+	}}, nil)
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		{ID: "db-container", Mounts: []types.MountPoint{{Type: mount.TypeVolume, Name: "pgdata"}}},
+		{ID: "unmanaged", Mounts: []types.MountPoint{{Type: mount.TypeVolume, Name: "cache"}}},
+	}, nil)
+
+	w := doRequest(router, "GET", "/volumes", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var volumes []volumeInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &volumes))
+	require.Len(t, volumes, 2)
+	assert.Equal(t, "cache", volumes[0].Name)
+	assert.Nil(t, volumes[0].UsageData)
+	assert.Empty(t, volumes[0].Services)
+	assert.Equal(t, int64(4096), volumes[1].UsageData.Size)
+	assert.Equal(t, []serviceRef{{ID: service.ID, Name: "db", EnvironmentID: 1}}, volumes[1].Services)
+}
+
+// expectVolumeHelper expects a helper container mounting the volume "data"
+// read-only to be created and removed.
+func expectVolumeHelper(mockClient *MockDockerClient, cmd func([]string) bool) {
+	mockClient.On("VolumeInspect", mock.Anything, "data").Return(volume.Volume{Name: "data"}, nil)
+	mockClient.On("ImageInspectWithRaw", mock.Anything, volumeHelperImage).Return(types.ImageInspect{}, []byte{}, nil)
+	mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(config *container.Config) bool {
+		return config.Labels[helperLabel] != "" && cmd(config.Cmd)
+	}), mock.MatchedBy(func(hostConfig *container.HostConfig) bool {
+		return len(hostConfig.Mounts) == 1 && hostConfig.Mounts[0].Source == "data" && hostConfig.Mounts[0].ReadOnly
+	}), mock.Anything, mock.Anything, "").Return(container.CreateResponse{ID: "helper"}, nil).Once()
+	mockClient.On("ContainerRemove", mock.Anything, "helper", mock.Anything).Return(nil).Once()
+}
+
+func TestListVolumeFiles(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.GET("/volumes/:name/files", ListVolumeFiles)
+
+	// Paths cannot escape the volume.
+	expectVolumeHelper(mockClient, func(cmd []string) bool { return cmd[0] == "find" && cmd[1] == "/volume/etc" })
+	mockClient.On("ContainerStart", mock.Anything, "helper", mock.Anything).Return(nil)
+	statusCh := make(chan container.WaitResponse, 1)
+	statusCh <- container.WaitResponse{StatusCode: 0}
+	mockClient.On("ContainerWait", mock.Anything, "helper", container.WaitConditionNotRunning).Return((<-chan container.WaitResponse)(statusCh), (<-chan error)(make(chan error)))
+	var logs bytes.Buffer
+	stdcopy.NewStdWriter(&logs, stdcopy.Stdout).Write([]byte("regular file/12/1700000000//volume/etc/app.conf\ndirectory/4096/1700000000//volume/etc/conf.d\n"))
+	mockClient.On("ContainerLogs", mock.Anything, "helper", mock.Anything).Return(io.NopCloser(&logs), nil)
+
+	w := doRequest(router, "GET", "/volumes/data/files?path=../etc", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listing struct {
+		Path    string        `json:"path"`
+		Entries []volumeEntry `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+	assert.Equal(t, "/etc", listing.Path)
+	assert.Equal(t, []volumeEntry{
+		{Name: "conf.d", Path: "/etc/conf.d", Type: "directory", Size: 4096, ModifiedAt: time.Unix(1700000000, 0).UTC()},
+		{Name: "app.conf", Path: "/etc/app.conf", Type: "file", Size: 12, ModifiedAt: time.Unix(1700000000, 0).UTC()},
+	}, listing.Entries)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDownloadVolumeFile(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.GET("/volumes/:name/download", DownloadVolumeFile)
+
+	expectVolumeHelper(mockClient, func([]string) bool { return true })
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	writer.WriteHeader(&tar.Header{Name: "app.conf", Mode: 0o644, Size: 12})
+	writer.Write([]byte("listen 8080\n"))
+	writer.Close()
+	mockClient.On("CopyFromContainer", mock.Anything, "helper", "/volume/etc/app.conf").Return(io.NopCloser(&archive), types.ContainerPathStat{Name: "app.conf", Size: 12, Mode: 0o644}, nil)
+
+	w := doRequest(router, "GET", "/volumes/data/download?path=/etc/app.conf", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "listen 8080\n", w.Body.String())
+	assert.Equal(t, `attachment; filename="app.conf"`, w.Header().Get("Content-Disposition"))
+	mockClient.AssertExpectations(t)
+}
+
+func TestDownloadVolumeFileRejectsSymlinks(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	router := setupTestRouter(mockClient)
+	router.GET("/volumes/:name/download", DownloadVolumeFile)
+
+	expectVolumeHelper(mockClient, func([]string) bool { return true })
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	writer.WriteHeader(&tar.Header{Name: "current", Typeflag: tar.TypeSymlink, Linkname: "releases/2"})
+	writer.Close()
+	mockClient.On("CopyFromContainer", mock.Anything, "helper", "/volume/current").Return(io.NopCloser(&archive), types.ContainerPathStat{Name: "current", Mode: os.ModeSymlink | 0o777, LinkTarget: "/volume/releases/2"}, nil)
+
+	w := doRequest(router, "GET", "/volumes/data/download?path=/current", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Symbolic links cannot be downloaded")
+}
@@ -11,16 +11,38 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
+// AllowedOrigins are the origins of the web UI allowed to open WebSockets,
+// the same ones CORS allows to call the API.
+var AllowedOrigins []string
+
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
+	CheckOrigin: checkOrigin,
+}
+
+// checkOrigin accepts WebSocket handshakes from the allowed origins and from
+// the API's own origin, so other sites cannot open sockets with the cookies
+// of a logged-in user. Requests without an Origin header do not come from a
+// browser and are accepted; they must authenticate like any other request.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
 		return true
-	},
+	}
+	for _, allowed := range AllowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
 }
 
 // StreamStats handles streaming live stats for a container.
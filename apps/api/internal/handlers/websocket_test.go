@@ -0,0 +1,34 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	AllowedOrigins = []string{"https://dockman.example.com"}
+	defer func() { AllowedOrigins = nil }()
+
+	for origin, want := range map[string]bool{
+		"":                            true,
+		"https://dockman.example.com": true,
+		"https://DockMan.example.com": true,
+		"http://api.internal:8080":    true,
+		"https://evil.example.com":    false,
+		"http://dockman.example.com":  false,
+		"null":                        false,
+	} {
+		req := httptest.NewRequest("GET", "http://api.internal:8080/api/containers/web/stats", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		assert.Equal(t, want, checkOrigin(req), origin)
+	}
+}
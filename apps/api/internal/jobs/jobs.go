@@ -0,0 +1,278 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+// Package jobs runs long operations such as deployments in the background and
+// streams their output to any number of clients while they run.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/models"
+)
+
+// Event types sent to clients attached to a job.
+const (
+	EventOutput   = "output"
+	EventProgress = "progress"
+	EventStatus   = "status"
+)
+
+// subscriberBuffer is the number of events buffered per attached client. A
+// client that falls further behind is detached rather than slowing the job.
+const subscriberBuffer = 256
+
+var (
+	// ErrBusy is returned when a job holding the same lock is still running.
+	ErrBusy = errors.New("another job is already running")
+	// ErrNotRunning is returned when canceling a job that is not running.
+	ErrNotRunning = errors.New("job is not running")
+)
+
+// Func is the work of a job. It writes its progress to out and must return
+// promptly once ctx is canceled.
+type Func func(ctx context.Context, out io.Writer) error
+
+// Event is a message sent to clients attached to a job: a chunk of output,
+// structured progress reported by the job, or the final status once the job
+// finishes.
+type Event struct {
+	Type     string      `json:"type"`
+	Data     string      `json:"data,omitempty"`
+	Progress interface{} `json:"progress,omitempty"`
+	Status   string      `json:"status,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// run is the in-memory state of a running job.
+type run struct {
+	mu          sync.Mutex
+	output      []byte
+	progress    interface{}
+	subscribers map[chan Event]struct{}
+	cancel      context.CancelFunc
+	done        chan struct{}
+	lock        string
+}
+
+// Write records output and forwards it to attached clients.
+func (r *run) Write(data []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output = append(r.output, data...)
+	r.broadcast(Event{Type: EventOutput, Data: string(data)})
+	return len(data), nil
+}
+
+// Report sends structured progress, such as a percentage, to the clients
+// attached to the job whose output is out. Clients attaching later receive
+// the latest progress reported. It does nothing when out is not the output
+// of a job.
+func Report(out io.Writer, progress interface{}) {
+	r, ok := out.(*run)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress = progress
+	r.broadcast(Event{Type: EventProgress, Progress: progress})
+}
+
+// broadcast sends an event to attached clients. r.mu must be held.
+func (r *run) broadcast(event Event) {
+	for events := range r.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(r.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+var (
+	mu    sync.Mutex
+	runs  = map[uint]*run{}
+	locks = map[string]uint{}
+)
+
+// Start saves job and runs fn in the background. Only one job per lock runs
+// at a time; Start returns ErrBusy if another job holds lock. An empty lock
+// never conflicts.
+func Start(job *models.Job, lock string, fn Func) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, busy := locks[lock]; busy && lock != "" {
+		return ErrBusy
+	}
+
+	job.Status = models.JobQueued
+	if err := database.DB.Create(job).Error; err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &run{subscribers: map[chan Event]struct{}{}, cancel: cancel, done: make(chan struct{}), lock: lock}
+	runs[job.ID] = r
+	if lock != "" {
+		locks[lock] = job.ID
+	}
+
+	// The job runs on its own copy so the caller can read job safely.
+	running := *job
+	go execute(ctx, &running, r, fn)
+	return nil
+}
+
+// execute runs a job and records its outcome.
+func execute(ctx context.Context, job *models.Job, r *run, fn Func) {
+	started := time.Now()
+	job.Status = models.JobRunning
+	job.StartedAt = &started
+	if err := database.DB.Model(job).Updates(map[string]interface{}{"status": job.Status, "started_at": started}).Error; err != nil {
+		log.Printf("Error marking job %d as running: %v", job.ID, err)
+	}
+
+	err := safeRun(ctx, r, fn)
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	switch {
+	case ctx.Err() != nil:
+		job.Status = models.JobCanceled
+		job.Error = "canceled"
+	case err != nil:
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = models.JobSucceeded
+	}
+
+	r.mu.Lock()
+	job.Output = string(r.output)
+	if err := database.DB.Save(job).Error; err != nil {
+		log.Printf("Error saving job %d: %v", job.ID, err)
+	}
+	r.broadcast(Event{Type: EventStatus, Status: job.Status, Error: job.Error})
+	for events := range r.subscribers {
+		close(events)
+	}
+	r.subscribers = nil
+	r.mu.Unlock()
+
+	mu.Lock()
+	delete(runs, job.ID)
+	if r.lock != "" {
+		delete(locks, r.lock)
+	}
+	mu.Unlock()
+	r.cancel()
+	close(r.done)
+}
+
+// safeRun runs fn, turning a panic into an error so a broken job cannot take
+// the server down or keep its lock forever.
+func safeRun(ctx context.Context, out io.Writer, fn Func) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return fn(ctx, out)
+}
+
+// Attach returns the events that replay a job so far, its output and latest
+// progress, and a channel of the events that follow them. The channel is
+// closed after the final status event. detach must be called once the
+// client goes away.
+func Attach(id uint) (backlog []Event, events <-chan Event, detach func(), err error) {
+	mu.Lock()
+	r, running := runs[id]
+	mu.Unlock()
+
+	if running {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		// The job may have finished between the two locks; its final state is then in the database.
+		if r.subscribers != nil {
+			ch := make(chan Event, subscriberBuffer)
+			r.subscribers[ch] = struct{}{}
+			detach = func() {
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				if _, ok := r.subscribers[ch]; ok {
+					delete(r.subscribers, ch)
+					close(ch)
+				}
+			}
+			if len(r.output) > 0 {
+				backlog = append(backlog, Event{Type: EventOutput, Data: string(r.output)})
+			}
+			if r.progress != nil {
+				backlog = append(backlog, Event{Type: EventProgress, Progress: r.progress})
+			}
+			return backlog, ch, detach, nil
+		}
+	}
+
+	var job models.Job
+	if err := database.DB.First(&job, id).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	if job.Output != "" {
+		backlog = append(backlog, Event{Type: EventOutput, Data: job.Output})
+	}
+	ch := make(chan Event, 1)
+	if job.Finished() {
+		ch <- Event{Type: EventStatus, Status: job.Status, Error: job.Error}
+	}
+	close(ch)
+	return backlog, ch, func() {}, nil
+}
+
+// Cancel asks a running job to stop.
+func Cancel(id uint) error {
+	mu.Lock()
+	defer mu.Unlock()
+	r, ok := runs[id]
+	if !ok {
+		return ErrNotRunning
+	}
+	r.cancel()
+	return nil
+}
+
+// Wait blocks until a job is no longer running.
+func Wait(id uint) {
+	mu.Lock()
+	r, ok := runs[id]
+	mu.Unlock()
+	if ok {
+		<-r.done
+	}
+}
+
+// Recover marks jobs and deployments left running by a previous process as
+// failed, since nothing will ever finish them.
+func Recover() error {
+	interrupted := map[string]interface{}{"status": models.JobFailed, "error": "interrupted by a server restart", "finished_at": time.Now()}
+	err := database.DB.Model(&models.Job{}).
+		Where("status IN ?", []string{models.JobQueued, models.JobRunning}).
+		Updates(interrupted).Error
+	if err != nil {
+		return err
+	}
+	interrupted["status"] = models.DeploymentFailed
+	return database.DB.Model(&models.Deployment{}).
+		Where("status = ?", models.DeploymentRunning).
+		Updates(interrupted).Error
+}
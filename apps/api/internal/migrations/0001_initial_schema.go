@@ -0,0 +1,226 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// initialSchema creates the tables as they were when migrations replaced
+// AutoMigrate. Databases created before that already have them, possibly
+// missing recent columns: the migration adds whatever is missing and keeps
+// their data. Columns holding JSON are declared as the strings they are
+// stored as.
+var initialSchema = Migration{
+	Version: 1,
+	Name:    "initial schema",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(initialTables...)
+	},
+	Drops: []string{
+		"registry_credentials", "jobs", "deployments", "audit_events", "api_keys", "environment_members",
+		"project_members", "sessions", "users", "environment_variables", "services", "environments", "projects",
+	},
+	Down: func(tx *gorm.DB) error {
+		for i := len(initialTables) - 1; i >= 0; i-- {
+			if err := tx.Migrator().DropTable(initialTables[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// initialTables lists the tables of the initial schema, tables referenced by
+// others first.
+var initialTables = []interface{}{
+	&projectV1{}, &environmentV1{}, &serviceV1{}, &environmentVariableV1{}, &userV1{}, &sessionV1{},
+	&projectMemberV1{}, &environmentMemberV1{}, &apiKeyV1{}, &auditEventV1{}, &deploymentV1{}, &jobV1{},
+	&registryCredentialV1{},
+}
+
+type projectV1 struct {
+	gorm.Model
+	Name         string
+	Description  string
+	Environments []environmentV1 `gorm:"foreignKey:ProjectID"`
+}
+
+func (projectV1) TableName() string { return "projects" }
+
+type environmentV1 struct {
+	gorm.Model
+	Name      string
+	ProjectID uint
+	Services  []serviceV1             `gorm:"foreignKey:EnvironmentID"`
+	Variables []environmentVariableV1 `gorm:"foreignKey:EnvironmentID"`
+}
+
+func (environmentV1) TableName() string { return "environments" }
+
+type serviceV1 struct {
+	gorm.Model
+	Name            string
+	EnvironmentID   uint
+	ParentServiceID *uint
+	SubServices     []serviceV1 `gorm:"foreignKey:ParentServiceID"`
+	Type            string
+	Image           string
+	ContainerID     string
+	Spec            string
+	Status          string
+	Health          string
+	StatusChangedAt *time.Time
+	ComposePath     string
+	ComposeProject  string `gorm:"index"`
+	Ports           string
+	Volumes         string
+	DependsOn       string
+	GitRepoURL      string
+	GitBranch       string
+	WebhookID       string
+}
+
+func (serviceV1) TableName() string { return "services" }
+
+type environmentVariableV1 struct {
+	gorm.Model
+	Key           string `gorm:"uniqueIndex:idx_env_key"`
+	Value         string
+	EnvironmentID uint `gorm:"uniqueIndex:idx_env_key"`
+}
+
+func (environmentVariableV1) TableName() string { return "environment_variables" }
+
+type userV1 struct {
+	gorm.Model
+	Username     string `gorm:"uniqueIndex"`
+	PasswordHash string
+	IsAdmin      bool
+}
+
+func (userV1) TableName() string { return "users" }
+
+type sessionV1 struct {
+	gorm.Model
+	TokenHash string    `gorm:"uniqueIndex"`
+	UserID    uint      `gorm:"index"`
+	User      userV1    `gorm:"constraint:OnDelete:CASCADE"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (sessionV1) TableName() string { return "sessions" }
+
+type projectMemberV1 struct {
+	gorm.Model
+	ProjectID uint `gorm:"uniqueIndex:idx_project_member"`
+	UserID    uint `gorm:"uniqueIndex:idx_project_member"`
+	User      userV1
+	Role      string
+}
+
+func (projectMemberV1) TableName() string { return "project_members" }
+
+type environmentMemberV1 struct {
+	gorm.Model
+	EnvironmentID uint `gorm:"uniqueIndex:idx_environment_member"`
+	UserID        uint `gorm:"uniqueIndex:idx_environment_member"`
+	User          userV1
+	Role          string
+}
+
+func (environmentMemberV1) TableName() string { return "environment_members" }
+
+type apiKeyV1 struct {
+	gorm.Model
+	Name       string
+	Prefix     string
+	SecretHash string `gorm:"uniqueIndex"`
+	Scopes     string
+	UserID     uint   `gorm:"index"`
+	User       userV1 `gorm:"constraint:OnDelete:CASCADE"`
+	ProjectID  *uint
+	RateLimit  int
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (apiKeyV1) TableName() string { return "api_keys" }
+
+type auditEventV1 struct {
+	ID         uint      `gorm:"primarykey"`
+	CreatedAt  time.Time `gorm:"index"`
+	ActorID    *uint     `gorm:"index"`
+	Actor      string
+	APIKeyID   *uint
+	ClientIP   string
+	Action     string `gorm:"index"`
+	Method     string
+	Path       string
+	TargetType string `gorm:"index"`
+	TargetID   string
+	ProjectID  *uint `gorm:"index"`
+	Changes    string
+	StatusCode int
+	Result     string
+	Error      string
+}
+
+func (auditEventV1) TableName() string { return "audit_events" }
+
+type deploymentV1 struct {
+	gorm.Model
+	ServiceID     uint `gorm:"index"`
+	Kind          string
+	Status        string
+	Image         string
+	ImageDigest   string
+	Spec          string
+	ComposeFile   string `gorm:"type:text"`
+	ImageDigests  string
+	Replicas      string
+	EnvHash       string
+	EnvSnapshot   string `gorm:"type:text"`
+	TriggeredByID *uint
+	TriggeredBy   string
+	RollbackOfID  *uint
+	StartedAt     time.Time
+	FinishedAt    *time.Time
+	Output        string `gorm:"type:text"`
+	Error         string
+}
+
+func (deploymentV1) TableName() string { return "deployments" }
+
+type jobV1 struct {
+	gorm.Model
+	Kind         string
+	Status       string `gorm:"index"`
+	ServiceID    *uint  `gorm:"index"`
+	DeploymentID *uint
+	CreatedByID  *uint
+	CreatedBy    string
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+	Output       string `gorm:"type:text"`
+	Error        string
+}
+
+func (jobV1) TableName() string { return "jobs" }
+
+type registryCredentialV1 struct {
+	gorm.Model
+	Registry  string `gorm:"index"`
+	ProjectID *uint  `gorm:"index"`
+	Username  string
+	Password  string
+	Project   projectV1 `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (registryCredentialV1) TableName() string { return "registry_credentials" }
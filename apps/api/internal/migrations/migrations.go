@@ -0,0 +1,192 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+// Package migrations evolves the database schema through ordered, versioned
+// migrations, recorded in the schema_migrations table as they are applied.
+//
+// A migration must not use the types of the models package, which describe
+// the latest schema: it declares the tables it creates or changes as they are
+// at its version, so it keeps producing the same schema as models evolve.
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a change to the schema that can be applied and reverted.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+	// Drops lists the tables Down drops, with all their data.
+	Drops []string
+}
+
+// all lists every migration, in the order they are applied. Versions must
+// increase, and a migration must never change once released.
+var all = []Migration{
+	initialSchema,
+}
+
+// Latest returns the version of the last migration.
+func Latest() uint {
+	return all[len(all)-1].Version
+}
+
+// schemaMigration records an applied migration.
+type schemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName implements gorm's schema.Tabler.
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// ErrNewerSchema is returned when the database was migrated by a newer
+// version of DockMan.
+var ErrNewerSchema = errors.New("the database schema is newer than this version of DockMan")
+
+// Status is the state of a migration in a database.
+type Status struct {
+	Migration
+	// AppliedAt is nil for a pending migration.
+	AppliedAt *time.Time
+}
+
+// applied returns the migrations applied to db by version.
+func applied(db *gorm.DB) (map[uint]schemaMigration, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return map[uint]schemaMigration{}, nil
+	}
+	var records []schemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	byVersion := make(map[uint]schemaMigration, len(records))
+	for _, record := range records {
+		byVersion[record.Version] = record
+	}
+	return byVersion, nil
+}
+
+// Check returns ErrNewerSchema if db has migrations applied that this
+// version of DockMan does not know about.
+func Check(db *gorm.DB) error {
+	records, err := applied(db)
+	if err != nil {
+		return err
+	}
+	var newest uint
+	for version := range records {
+		newest = max(newest, version)
+	}
+	if newest > Latest() {
+		return fmt.Errorf("%w: it is at version %d, this version only knows up to %d", ErrNewerSchema, newest, Latest())
+	}
+	return nil
+}
+
+// Up applies the pending migrations in order, each in its own transaction,
+// and returns those it applied.
+func Up(db *gorm.DB) ([]Migration, error) {
+	if err := Check(db); err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, err
+	}
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range all {
+		if _, ok := records[migration.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// ErrDataLoss is returned when reverting a migration would drop tables and
+// the revert was not forced.
+var ErrDataLoss = errors.New("reverting would drop tables and all their data")
+
+// Down reverts the last steps applied migrations, latest first, and returns
+// those it reverted. Unless force is set, nothing is reverted if one of the
+// migrations drops tables.
+func Down(db *gorm.DB, steps int, force bool) ([]Migration, error) {
+	if err := Check(db); err != nil {
+		return nil, err
+	}
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for i := len(all) - 1; i >= 0 && len(pending) < steps; i-- {
+		if _, ok := records[all[i].Version]; ok {
+			pending = append(pending, all[i])
+		}
+	}
+	if !force {
+		for _, migration := range pending {
+			if len(migration.Drops) > 0 {
+				return nil, fmt.Errorf("%w: migration %d (%s) drops %s", ErrDataLoss, migration.Version, migration.Name, strings.Join(migration.Drops, ", "))
+			}
+		}
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("revert migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// List returns the state of every migration in db.
+func List(db *gorm.DB) ([]Status, error) {
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(all))
+	for _, migration := range all {
+		status := Status{Migration: migration}
+		if record, ok := records[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package migrations
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"docker-manager/api/internal/config"
+	"docker-manager/api/internal/database"
+	"docker-manager/api/internal/database/databasetest"
+	"docker-manager/api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// latestModels are the models whose tables the migrations must produce.
+var latestModels = []interface{}{&models.Project{}, &models.Environment{}, &models.Service{}, &models.EnvironmentVariable{}, &models.User{}, &models.Session{}, &models.ProjectMember{}, &models.EnvironmentMember{}, &models.APIKey{}, &models.AuditEvent{}, &models.Deployment{}, &models.Job{}, &models.RegistryCredential{}}
+
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := database.Open(config.Database{Driver: config.DriverSQLite, DSN: filepath.Join(t.TempDir(), "dockman.db")}, &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+// sqliteSchema returns the statements creating the tables and indexes of db.
+// Foreign key constraints are sorted, as gorm declares them in no set order.
+func sqliteSchema(t *testing.T, db *gorm.DB) map[string]string {
+	var rows []struct{ Name, SQL string }
+	require.NoError(t, db.Raw("SELECT name, sql FROM sqlite_master WHERE sql IS NOT NULL AND name <> 'schema_migrations'").Scan(&rows).Error)
+	schema := make(map[string]string, len(rows))
+	for _, row := range rows {
+		schema[row.Name] = sortConstraints(row.SQL)
+	}
+	return schema
+}
+
+func sortConstraints(sql string) string {
+	columns, constraints, ok := strings.Cut(strings.TrimSuffix(sql, ")"), ",CONSTRAINT ")
+	if !ok {
+		return sql
+	}
+	clauses := strings.Split(constraints, ",CONSTRAINT ")
+	sort.Strings(clauses)
+	return columns + ",CONSTRAINT " + strings.Join(clauses, ",CONSTRAINT ") + ")"
+}
+
+func TestMigrationsProduceTheSchemaOfTheModels(t *testing.T) {
+	migrated := openSQLite(t)
+	_, err := Up(migrated)
+	require.NoError(t, err)
+	automigrated := openSQLite(t)
+	require.NoError(t, automigrated.AutoMigrate(latestModels...))
+
+	assert.Equal(t, sqliteSchema(t, automigrated), sqliteSchema(t, migrated))
+}
+
+func TestUpAdoptsDatabasesCreatedByAutoMigrate(t *testing.T) {
+	db, err := databasetest.Open()
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(latestModels...))
+	require.NoError(t, db.Create(&models.Project{Name: "shop"}).Error)
+
+	applied, err := Up(db)
+	require.NoError(t, err)
+	require.Len(t, applied, len(all))
+	var project models.Project
+	require.NoError(t, db.First(&project).Error)
+	assert.Equal(t, "shop", project.Name)
+
+	applied, err = Up(db)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	statuses, err := List(db)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, "migration %d", status.Version)
+	}
+}
+
+func TestDownRevertsMigrations(t *testing.T) {
+	db, err := databasetest.Open()
+	require.NoError(t, err)
+	_, err = Up(db)
+	require.NoError(t, err)
+
+	tables, err := db.Migrator().GetTables()
+	require.NoError(t, err)
+	var created []string
+	for _, table := range tables {
+		if table != "schema_migrations" && table != "sqlite_sequence" {
+			created = append(created, table)
+		}
+	}
+	assert.ElementsMatch(t, created, initialSchema.Drops)
+
+	reverted, err := Down(db, len(all), false)
+	assert.ErrorIs(t, err, ErrDataLoss)
+	assert.ErrorContains(t, err, "migration 1 (initial schema) drops registry_credentials, jobs")
+	assert.Empty(t, reverted)
+	assert.True(t, db.Migrator().HasTable(&models.Project{}))
+
+	reverted, err = Down(db, len(all), true)
+	require.NoError(t, err)
+	require.Len(t, reverted, len(all))
+	assert.Equal(t, Latest(), reverted[0].Version)
+	assert.False(t, db.Migrator().HasTable(&models.Project{}))
+	statuses, err := List(db)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.Nil(t, status.AppliedAt, "migration %d", status.Version)
+	}
+
+	reverted, err = Down(db, 1, false)
+	require.NoError(t, err)
+	assert.Empty(t, reverted)
+}
+
+func TestRefusesNewerSchema(t *testing.T) {
+	db, err := databasetest.Open()
+	require.NoError(t, err)
+	_, err = Up(db)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&schemaMigration{Version: Latest() + 1, Name: "from the future"}).Error)
+
+	assert.ErrorIs(t, Check(db), ErrNewerSchema)
+	_, err = Up(db)
+	assert.ErrorIs(t, err, ErrNewerSchema)
+	_, err = Down(db, 1, true)
+	assert.ErrorIs(t, err, ErrNewerSchema)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scopes an API key can be granted. Like roles, each scope includes the ones
+// before it: read < write < deploy < admin.
+const (
+	ScopeRead   = "read"
+	ScopeWrite  = "write"
+	ScopeDeploy = "deploy"
+	ScopeAdmin  = "admin"
+)
+
+// scopeRoles maps each scope to the highest role it lets a key act with.
+var scopeRoles = map[string]Role{
+	ScopeRead:   RoleViewer,
+	ScopeWrite:  RoleDeveloper,
+	ScopeDeploy: RoleDeployer,
+	ScopeAdmin:  RoleAdmin,
+}
+
+// APIKey lets automation such as CI pipelines call the API on behalf of a
+// user. Only a hash of the secret is stored; the secret itself is shown once
+// when the key is created.
+type APIKey struct {
+	gorm.Model
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-" gorm:"uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	User       User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	ProjectID  *uint      `json:"project_id"`
+	RateLimit  int        `json:"rate_limit"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// ValidScope reports whether scope is a known API key scope.
+func ValidScope(scope string) bool {
+	_, ok := scopeRoles[scope]
+	return ok
+}
+
+// MaxRole returns the highest role the scopes of the key allow.
+func (k *APIKey) MaxRole() Role {
+	var max Role
+	for _, scope := range k.Scopes {
+		if role, ok := scopeRoles[scope]; ok && role.Allows(max) {
+			max = role
+		}
+	}
+	return max
+}
+
+// Active reports whether the key can still be used at t.
+func (k *APIKey) Active(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
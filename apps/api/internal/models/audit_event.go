@@ -0,0 +1,36 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package models
+
+import "time"
+
+// AuditEvent records a mutating API request: who did what to which resource,
+// the redacted changes they sent and how it turned out. Events are never
+// updated or deleted, so they do not use gorm.Model.
+type AuditEvent struct {
+	ID         uint                   `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time              `json:"created_at" gorm:"index"`
+	ActorID    *uint                  `json:"actor_id" gorm:"index"`
+	Actor      string                 `json:"actor"`
+	APIKeyID   *uint                  `json:"api_key_id"`
+	ClientIP   string                 `json:"client_ip"`
+	Action     string                 `json:"action" gorm:"index"`
+	Method     string                 `json:"method"`
+	Path       string                 `json:"path"`
+	TargetType string                 `json:"target_type" gorm:"index"`
+	TargetID   string                 `json:"target_id"`
+	ProjectID  *uint                  `json:"project_id" gorm:"index"`
+	Changes    map[string]interface{} `json:"changes,omitempty" gorm:"serializer:json"`
+	StatusCode int                    `json:"status_code"`
+	Result     string                 `json:"result"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Audit event results.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
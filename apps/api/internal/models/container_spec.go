@@ -0,0 +1,244 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+)
+
+// Restart policies of a container.
+const (
+	RestartNo            = "no"
+	RestartAlways        = "always"
+	RestartOnFailure     = "on-failure"
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// Mount types.
+const (
+	MountBind   = "bind"
+	MountVolume = "volume"
+)
+
+// minMemory is the smallest memory limit Docker accepts.
+const minMemory = 6 * 1024 * 1024
+
+// reservedLabelPrefixes are label prefixes DockMan and compose set themselves.
+var reservedLabelPrefixes = []string{"dockman.", "com.docker.compose."}
+
+// volumeNamePattern matches the names Docker accepts for volumes.
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// envKeyPattern matches valid environment variable names.
+var envKeyPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ContainerSpec is the configuration of the container of a container service,
+// applied every time it is deployed.
+type ContainerSpec struct {
+	Ports      []PortBinding `json:"ports,omitempty"`
+	Mounts     []Mount       `json:"mounts,omitempty"`
+	Command    []string      `json:"command,omitempty"`
+	Entrypoint []string      `json:"entrypoint,omitempty"`
+	// Env holds variables specific to the service. They override the
+	// variables of its environment and, unlike them, are not encrypted, so
+	// secrets belong in the environment.
+	Env map[string]string `json:"env,omitempty"`
+	// RestartPolicy is one of the Restart* policies, "no" if empty.
+	// MaxRetries only applies to "on-failure".
+	RestartPolicy string `json:"restart_policy,omitempty"`
+	MaxRetries    int    `json:"max_retries,omitempty"`
+	// CPUs limits the number of CPUs the container may use, such as 0.5.
+	CPUs float64 `json:"cpus,omitempty"`
+	// Memory limits the memory of the container, such as "512m" or "2g".
+	Memory      string            `json:"memory,omitempty"`
+	Healthcheck *Healthcheck      `json:"healthcheck,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	User        string            `json:"user,omitempty"`
+}
+
+// PortBinding publishes a container port on the host. A zero HostPort lets
+// Docker pick a free port.
+type PortBinding struct {
+	HostIP        string `json:"host_ip,omitempty"`
+	HostPort      int    `json:"host_port,omitempty"`
+	ContainerPort int    `json:"container_port"`
+	// Protocol is "tcp", "udp" or "sctp", "tcp" if empty.
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// Mount mounts a host path or a named volume into the container.
+type Mount struct {
+	Type     string `json:"type"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+// Healthcheck tells Docker how to check that the container is healthy.
+// Durations are written like "30s" or "1m30s".
+type Healthcheck struct {
+	// Test is ["CMD", args...] to run a command, ["CMD-SHELL", command] to
+	// run it with the container's shell, or ["NONE"] to disable the check
+	// inherited from the image.
+	Test        []string `json:"test"`
+	Interval    string   `json:"interval,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	StartPeriod string   `json:"start_period,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+}
+
+// MemoryBytes returns the memory limit in bytes, 0 if there is none.
+func (s *ContainerSpec) MemoryBytes() (int64, error) {
+	if s.Memory == "" {
+		return 0, nil
+	}
+	return units.RAMInBytes(s.Memory)
+}
+
+// Validate checks a spec, reporting every problem found with the field it is
+// about, such as "ports[0].container_port: must be between 1 and 65535",
+// separated by semicolons.
+func (s *ContainerSpec) Validate() error {
+	var problems []string
+	fail := func(field, format string, args ...interface{}) {
+		problems = append(problems, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	published := map[string]bool{}
+	for i, port := range s.Ports {
+		field := fmt.Sprintf("ports[%d]", i)
+		if port.ContainerPort < 1 || port.ContainerPort > 65535 {
+			fail(field+".container_port", "must be between 1 and 65535")
+		}
+		if port.HostPort < 0 || port.HostPort > 65535 {
+			fail(field+".host_port", "must be between 1 and 65535, or 0 for a random port")
+		}
+		switch port.Protocol {
+		case "", "tcp", "udp", "sctp":
+		default:
+			fail(field+".protocol", "must be tcp, udp or sctp")
+		}
+		if port.HostPort != 0 {
+			key := fmt.Sprintf("%s:%d/%s", port.HostIP, port.HostPort, port.Proto())
+			if published[key] {
+				fail(field+".host_port", "%d is already published", port.HostPort)
+			}
+			published[key] = true
+		}
+	}
+
+	targets := map[string]bool{}
+	for i, mount := range s.Mounts {
+		field := fmt.Sprintf("mounts[%d]", i)
+		switch mount.Type {
+		case MountBind:
+			if !path.IsAbs(mount.Source) {
+				fail(field+".source", "must be an absolute path on the host")
+			}
+		case MountVolume:
+			if !volumeNamePattern.MatchString(mount.Source) {
+				fail(field+".source", "must be a volume name of letters, digits, '_', '.' or '-'")
+			}
+		default:
+			fail(field+".type", "must be %s or %s", MountBind, MountVolume)
+		}
+		switch {
+		case !path.IsAbs(mount.Target):
+			fail(field+".target", "must be an absolute path in the container")
+		case targets[path.Clean(mount.Target)]:
+			fail(field+".target", "%s is already mounted", mount.Target)
+		}
+		targets[path.Clean(mount.Target)] = true
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(s.Env)) {
+		if !envKeyPattern.MatchString(key) {
+			fail("env", "%q is not a valid variable name", key)
+		}
+	}
+
+	switch s.RestartPolicy {
+	case "", RestartNo, RestartAlways, RestartUnlessStopped:
+		if s.MaxRetries != 0 {
+			fail("max_retries", "only applies to the %s restart policy", RestartOnFailure)
+		}
+	case RestartOnFailure:
+		if s.MaxRetries < 0 {
+			fail("max_retries", "must not be negative")
+		}
+	default:
+		fail("restart_policy", "must be %s, %s, %s or %s", RestartNo, RestartAlways, RestartOnFailure, RestartUnlessStopped)
+	}
+
+	if s.CPUs < 0 {
+		fail("cpus", "must not be negative")
+	}
+	if memory, err := s.MemoryBytes(); err != nil {
+		fail("memory", "must be a size such as 512m or 2g")
+	} else if memory != 0 && memory < minMemory {
+		fail("memory", "must be at least 6m")
+	}
+
+	if check := s.Healthcheck; check != nil {
+		switch {
+		case len(check.Test) == 0:
+			fail("healthcheck.test", "is required")
+		case check.Test[0] == "NONE":
+			if len(check.Test) != 1 {
+				fail("healthcheck.test", "NONE takes no arguments")
+			}
+		case check.Test[0] == "CMD" && len(check.Test) < 2:
+			fail("healthcheck.test", "CMD needs a command")
+		case check.Test[0] == "CMD-SHELL" && len(check.Test) != 2:
+			fail("healthcheck.test", "CMD-SHELL takes a single command line")
+		case check.Test[0] != "CMD" && check.Test[0] != "CMD-SHELL":
+			fail("healthcheck.test", "must start with CMD, CMD-SHELL or NONE")
+		}
+		durations := []struct{ field, value string }{
+			{"interval", check.Interval}, {"timeout", check.Timeout}, {"start_period", check.StartPeriod},
+		}
+		for _, d := range durations {
+			if d.value == "" {
+				continue
+			}
+			if duration, err := time.ParseDuration(d.value); err != nil || duration < time.Millisecond {
+				fail("healthcheck."+d.field, "must be a duration of at least 1ms, such as 30s")
+			}
+		}
+		if check.Retries < 0 {
+			fail("healthcheck.retries", "must not be negative")
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(s.Labels)) {
+		for _, prefix := range reservedLabelPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				fail("labels", "%q is reserved, labels may not start with %s", key, prefix)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Proto returns the protocol of the binding, "tcp" by default.
+func (p PortBinding) Proto() string {
+	if p.Protocol == "" {
+		return "tcp"
+	}
+	return p.Protocol
+}
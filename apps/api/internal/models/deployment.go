@@ -0,0 +1,143 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"docker-manager/api/internal/crypto"
+	"gorm.io/gorm"
+)
+
+// Deployment kinds.
+const (
+	DeploymentUp       = "up"
+	DeploymentScale    = "scale"
+	DeploymentRollback = "rollback"
+	DeploymentHeal     = "heal"
+)
+
+// Deployment statuses.
+const (
+	DeploymentRunning   = "running"
+	DeploymentSucceeded = "succeeded"
+	DeploymentFailed    = "failed"
+	DeploymentCanceled  = "canceled"
+)
+
+// Deployment records one up, redeploy, scale, rollback or heal of a service,
+// with a snapshot of everything that was applied so it can be re-applied
+// exactly.
+type Deployment struct {
+	gorm.Model
+	ServiceID uint   `json:"service_id" gorm:"index"`
+	Kind      string `json:"kind"`
+	Status    string `json:"status"`
+
+	// --- Snapshot ---
+	// For 'container' services, the image reference, the digest it resolved to
+	// and the container spec
+	Image       string         `json:"image,omitempty"`
+	ImageDigest string         `json:"image_digest,omitempty"`
+	Spec        *ContainerSpec `json:"spec,omitempty" gorm:"serializer:json"`
+	// For 'compose' services, the compose file contents, the digest of each
+	// service's image and the number of replicas of each service
+	ComposeFile  string            `json:"compose_file,omitempty" gorm:"type:text"`
+	ImageDigests map[string]string `json:"image_digests,omitempty" gorm:"serializer:json"`
+	Replicas     map[string]int    `json:"replicas,omitempty" gorm:"serializer:json"`
+	// EnvHash is a keyed digest, from crypto.Digest, of the environment variables
+	// that were applied.
+	// The variables themselves are kept encrypted in EnvSnapshot.
+	EnvHash     string `json:"env_hash"`
+	EnvSnapshot string `json:"-" gorm:"type:text"`
+
+	// --- Execution ---
+	TriggeredByID *uint      `json:"triggered_by_id"`
+	TriggeredBy   string     `json:"triggered_by"`
+	RollbackOfID  *uint      `json:"rollback_of_id,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	Output        string     `json:"output" gorm:"type:text"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// SetEnvironment stores the KEY=value variables a deployment applies, encrypted.
+func (d *Deployment) SetEnvironment(env []string, hash string) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	snapshot, err := crypto.Encrypt(string(data))
+	if err != nil {
+		return err
+	}
+	d.EnvSnapshot = snapshot
+	d.EnvHash = hash
+	return nil
+}
+
+// Environment returns the KEY=value variables a deployment applied.
+func (d *Deployment) Environment() ([]string, error) {
+	if d.EnvSnapshot == "" {
+		return nil, nil
+	}
+	data, err := crypto.Decrypt(d.EnvSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	var env []string
+	err = json.Unmarshal([]byte(data), &env)
+	return env, err
+}
+
+// ReencryptDeployments re-encrypts with the primary key the environment
+// snapshot of every deployment whose stored ciphertext matches stale,
+// decrypting it with decrypt, and returns how many were updated. Deployments
+// are processed in small transactions so the table is never locked for long.
+func ReencryptDeployments(db *gorm.DB, stale func(ciphertext string) bool, decrypt func(ciphertext string) (string, error)) (int, error) {
+	var ids []uint
+	if err := db.Model(&Deployment{}).Where("env_snapshot <> ''").Order("id").Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for start := 0; start < len(ids); start += reencryptBatchSize {
+		batch := ids[start:min(start+reencryptBatchSize, len(ids))]
+		count := 0
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var deployments []Deployment
+			if err := tx.Select("id", "env_snapshot").Find(&deployments, batch).Error; err != nil {
+				return err
+			}
+			for _, deployment := range deployments {
+				if !stale(deployment.EnvSnapshot) {
+					continue
+				}
+				plaintext, err := decrypt(deployment.EnvSnapshot)
+				if err != nil {
+					return fmt.Errorf("decrypt deployment %d: %w", deployment.ID, err)
+				}
+				snapshot, err := crypto.Encrypt(plaintext)
+				if err != nil {
+					return err
+				}
+				// Leave updated_at alone: the deployment itself did not change.
+				if err := tx.Model(&deployment).UpdateColumn("env_snapshot", snapshot).Error; err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		})
+		if err != nil {
+			return updated, err
+		}
+		updated += count
+	}
+	return updated, nil
+}
@@ -6,10 +6,15 @@
 package models
 
 import (
+	"fmt"
+
 	"docker-manager/api/internal/crypto"
 	"gorm.io/gorm"
 )
 
+// reencryptBatchSize is the number of rows re-encrypted per transaction.
+const reencryptBatchSize = 100
+
 // EnvironmentVariable represents a key-value pair for an environment.
 // The value is encrypted at rest in the database.
 type EnvironmentVariable struct {
@@ -30,13 +35,58 @@ func (ev *EnvironmentVariable) BeforeSave(tx *gorm.DB) (err error) {
 }
 
 // AfterFind is a GORM hook that decrypts the Value after retrieving it from the database.
+// A value that cannot be decrypted, because it was tampered with or its key is
+// missing, fails the query rather than leaking the ciphertext.
 func (ev *EnvironmentVariable) AfterFind(tx *gorm.DB) (err error) {
 	decryptedValue, err := crypto.Decrypt(ev.Value)
 	if err != nil {
-		// If decryption fails, it might be because the value is not encrypted (e.g. during creation)
-		// We can choose to ignore the error or handle it. For now, we'll just return the encrypted value.
-		return nil 
+		return fmt.Errorf("decrypt environment variable %d: %w", ev.ID, err)
 	}
 	ev.Value = decryptedValue
 	return nil
 }
+
+// ReencryptEnvironmentVariables re-encrypts with the primary key, in the
+// current format, every variable whose stored ciphertext matches stale,
+// decrypting it with decrypt, and returns how many were updated. Variables are processed in small transactions
+// so the table is never locked for long.
+func ReencryptEnvironmentVariables(db *gorm.DB, stale func(ciphertext string) bool, decrypt func(ciphertext string) (string, error)) (int, error) {
+	var ids []uint
+	if err := db.Model(&EnvironmentVariable{}).Order("id").Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for start := 0; start < len(ids); start += reencryptBatchSize {
+		batch := ids[start:min(start+reencryptBatchSize, len(ids))]
+		count := 0
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Skip the hooks to read the stored ciphertexts rather than the decrypted values.
+			var variables []EnvironmentVariable
+			if err := tx.Session(&gorm.Session{SkipHooks: true}).Find(&variables, batch).Error; err != nil {
+				return err
+			}
+			for i := range variables {
+				variable := &variables[i]
+				if !stale(variable.Value) {
+					continue
+				}
+				plaintext, err := decrypt(variable.Value)
+				if err != nil {
+					return fmt.Errorf("decrypt variable %d: %w", variable.ID, err)
+				}
+				variable.Value = plaintext
+				if err := tx.Save(variable).Error; err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		})
+		if err != nil {
+			return updated, err
+		}
+		updated += count
+	}
+	return updated, nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job statuses.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCanceled  = "canceled"
+)
+
+// Job is a long-running operation, such as a deployment, executed in the
+// background. Its output is kept once it finishes so clients can review it.
+type Job struct {
+	gorm.Model
+	Kind         string     `json:"kind"`
+	Status       string     `json:"status" gorm:"index"`
+	ServiceID    *uint      `json:"service_id,omitempty" gorm:"index"`
+	DeploymentID *uint      `json:"deployment_id,omitempty"`
+	CreatedByID  *uint      `json:"created_by_id"`
+	CreatedBy    string     `json:"created_by"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	Output       string     `json:"output,omitempty" gorm:"type:text"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// Finished reports whether the job has stopped running.
+func (j *Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCanceled
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package models
+
+import "gorm.io/gorm"
+
+// Role is a level of access to a project or environment. Each role grants
+// everything the roles before it do.
+type Role string
+
+const (
+	// RoleViewer can see projects, environments and services.
+	RoleViewer Role = "viewer"
+	// RoleDeveloper can also manage services and environment variables.
+	RoleDeveloper Role = "developer"
+	// RoleDeployer can also start, stop and scale services.
+	RoleDeployer Role = "deployer"
+	// RoleAdmin can also manage environments and members.
+	RoleAdmin Role = "admin"
+)
+
+// roleRanks orders the roles from least to most privileged.
+var roleRanks = map[Role]int{RoleViewer: 1, RoleDeveloper: 2, RoleDeployer: 3, RoleAdmin: 4}
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Allows reports whether r grants at least the access of required.
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// ProjectMember grants a user a role on every environment of a project.
+type ProjectMember struct {
+	gorm.Model
+	ProjectID uint `json:"project_id" gorm:"uniqueIndex:idx_project_member"`
+	UserID    uint `json:"user_id" gorm:"uniqueIndex:idx_project_member"`
+	User      User `json:"user,omitempty"`
+	Role      Role `json:"role"`
+}
+
+// EnvironmentMember overrides the project role of a member for one
+// environment, e.g. to let developers deploy to dev but not to prod.
+type EnvironmentMember struct {
+	gorm.Model
+	EnvironmentID uint `json:"environment_id" gorm:"uniqueIndex:idx_environment_member"`
+	UserID        uint `json:"user_id" gorm:"uniqueIndex:idx_environment_member"`
+	User          User `json:"user,omitempty"`
+	Role          Role `json:"role"`
+}
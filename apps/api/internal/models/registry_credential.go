@@ -0,0 +1,82 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package models
+
+import (
+	"fmt"
+
+	"docker-manager/api/internal/crypto"
+	"gorm.io/gorm"
+)
+
+// RegistryCredential holds the login to a private image registry, used to
+// pull images hosted on it. Credentials belong to a project, or to the whole
+// install when ProjectID is nil; a project's own credential for a registry
+// takes precedence. The password is encrypted at rest in the database.
+type RegistryCredential struct {
+	gorm.Model
+	Registry  string  `json:"registry" gorm:"index"`
+	ProjectID *uint   `json:"project_id" gorm:"index"`
+	Username  string  `json:"username"`
+	Password  string  `json:"-"`
+	Project   Project `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// BeforeSave is a GORM hook that encrypts the Password before saving it to the database.
+func (rc *RegistryCredential) BeforeSave(tx *gorm.DB) (err error) {
+	encryptedPassword, err := crypto.Encrypt(rc.Password)
+	if err != nil {
+		return err
+	}
+	rc.Password = encryptedPassword
+	return nil
+}
+
+// AfterFind is a GORM hook that decrypts the Password after retrieving it from the database.
+func (rc *RegistryCredential) AfterFind(tx *gorm.DB) (err error) {
+	return rc.decryptPassword()
+}
+
+// decryptPassword replaces the stored ciphertext of the Password with its plaintext.
+func (rc *RegistryCredential) decryptPassword() error {
+	decryptedPassword, err := crypto.Decrypt(rc.Password)
+	if err != nil {
+		return fmt.Errorf("decrypt registry credential %d: %w", rc.ID, err)
+	}
+	rc.Password = decryptedPassword
+	return nil
+}
+
+// ReencryptRegistryCredentials re-encrypts with the primary key every
+// registry password whose stored ciphertext matches stale, decrypting it with
+// decrypt, and returns how many were updated.
+func ReencryptRegistryCredentials(db *gorm.DB, stale func(ciphertext string) bool, decrypt func(ciphertext string) (string, error)) (int, error) {
+	updated := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Skip the hooks to read the stored ciphertexts rather than the decrypted passwords.
+		var credentials []RegistryCredential
+		if err := tx.Session(&gorm.Session{SkipHooks: true}).Find(&credentials).Error; err != nil {
+			return err
+		}
+		for i := range credentials {
+			credential := &credentials[i]
+			if !stale(credential.Password) {
+				continue
+			}
+			password, err := decrypt(credential.Password)
+			if err != nil {
+				return fmt.Errorf("decrypt registry credential %d: %w", credential.ID, err)
+			}
+			credential.Password = password
+			if err := tx.Save(credential).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
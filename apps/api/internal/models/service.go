@@ -5,7 +5,19 @@
 
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Service statuses, kept in sync with the state of the service's container
+// as reported by Docker.
+const (
+	ServiceRunning = "running"
+	ServiceExited  = "exited"
+	ServiceRemoved = "removed"
+)
 
 // Service represents a deployable unit within an environment.
 type Service struct {
@@ -25,9 +37,25 @@ type Service struct {
 	// For 'container'
 	Image       string `json:"image,omitempty"`
 	ContainerID string `json:"container_id,omitempty"`
+	// How the container is run: ports, mounts, limits, healthcheck...
+	Spec *ContainerSpec `json:"spec,omitempty" gorm:"serializer:json"`
+
+	// Last known state of the container: a status, the health reported by
+	// its healthcheck, if any, and when it last changed.
+	Status          string     `json:"status,omitempty"`
+	Health          string     `json:"health,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
 
 	// For 'compose'
 	ComposePath string `json:"compose_path,omitempty"`
+	// The compose project of a stack adopted from outside DockMan. Stacks
+	// DockMan deploys itself are named after the service.
+	ComposeProject string `json:"compose_project,omitempty" gorm:"index"`
+
+	// For sub-services of a 'compose' service, as declared in the compose file
+	Ports     []string `json:"ports,omitempty" gorm:"serializer:json"`
+	Volumes   []string `json:"volumes,omitempty" gorm:"serializer:json"`
+	DependsOn []string `json:"depends_on,omitempty" gorm:"serializer:json"`
 
 	// For 'database' (future use)
 	// DBType string `json:"db_type,omitempty"` 
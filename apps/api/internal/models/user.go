@@ -0,0 +1,30 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// User is an account that can sign in to DockMan.
+type User struct {
+	gorm.Model
+	Username     string `json:"username" gorm:"uniqueIndex"`
+	PasswordHash string `json:"-"`
+	IsAdmin      bool   `json:"is_admin"`
+}
+
+// Session is a signed-in user session. Only a hash of the session token is
+// stored, so a leaked database cannot be used to hijack sessions.
+type Session struct {
+	gorm.Model
+	TokenHash string    `gorm:"uniqueIndex"`
+	UserID    uint      `gorm:"index"`
+	User      User      `gorm:"constraint:OnDelete:CASCADE"`
+	ExpiresAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+// Package pull decodes the JSON progress stream of a Docker image pull into
+// per-layer progress and an overall percentage.
+package pull
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Layer statuses reported by the Docker daemon while pulling.
+const (
+	StatusWaiting          = "Waiting"
+	StatusPullingFSLayer   = "Pulling fs layer"
+	StatusDownloading      = "Downloading"
+	StatusVerifying        = "Verifying Checksum"
+	StatusDownloadComplete = "Download complete"
+	StatusExtracting       = "Extracting"
+	StatusPullComplete     = "Pull complete"
+	StatusAlreadyExists    = "Already exists"
+)
+
+// downloadShare is the part of a layer's progress taken by its download; the
+// rest is its extraction.
+const downloadShare = 0.8
+
+// Message is one line of the JSON stream returned by ImagePull.
+type Message struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// Layer is the progress of one image layer.
+type Layer struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+}
+
+// Progress is the state of a pull after the latest message.
+type Progress struct {
+	// Status is the latest message that is not about a single layer, such
+	// as "Pulling from library/nginx" or "Digest: sha256:...".
+	Status  string  `json:"status"`
+	Layers  []Layer `json:"layers"`
+	Percent float64 `json:"percent"`
+}
+
+// Tracker aggregates the messages of a pull.
+type Tracker struct {
+	status string
+	layers []Layer
+	index  map[string]int
+}
+
+// NewTracker returns a tracker for a pull that has not started yet.
+func NewTracker() *Tracker {
+	return &Tracker{index: map[string]int{}}
+}
+
+// Update applies a message and reports whether it changed the status of a
+// layer or of the pull, as opposed to only advancing a byte count.
+func (t *Tracker) Update(message Message) bool {
+	if message.ID == "" || !isLayerStatus(message.Status) {
+		changed := message.Status != t.status
+		t.status = message.Status
+		return changed
+	}
+
+	i, ok := t.index[message.ID]
+	if !ok {
+		i = len(t.layers)
+		t.index[message.ID] = i
+		t.layers = append(t.layers, Layer{ID: message.ID})
+	}
+	layer := &t.layers[i]
+	changed := layer.Status != message.Status
+	layer.Status = message.Status
+	if message.ProgressDetail.Total > 0 {
+		layer.Current = message.ProgressDetail.Current
+		layer.Total = message.ProgressDetail.Total
+	}
+	return changed
+}
+
+// Progress returns the current state of the pull.
+func (t *Tracker) Progress() Progress {
+	progress := Progress{Status: t.status, Layers: append([]Layer(nil), t.layers...)}
+	if len(t.layers) == 0 {
+		return progress
+	}
+	var done float64
+	for _, layer := range t.layers {
+		done += layerDone(layer)
+	}
+	progress.Percent = done / float64(len(t.layers)) * 100
+	return progress
+}
+
+// layerDone returns the completed fraction of a layer, counting its download
+// for downloadShare of the work and its extraction for the rest.
+func layerDone(layer Layer) float64 {
+	fraction := 0.0
+	if layer.Total > 0 {
+		fraction = min(float64(layer.Current)/float64(layer.Total), 1)
+	}
+	switch layer.Status {
+	case StatusDownloading:
+		return fraction * downloadShare
+	case StatusVerifying, StatusDownloadComplete:
+		return downloadShare
+	case StatusExtracting:
+		return downloadShare + fraction*(1-downloadShare)
+	case StatusPullComplete, StatusAlreadyExists:
+		return 1
+	}
+	return 0
+}
+
+// isLayerStatus reports whether a status describes a single layer. Messages
+// such as "Pulling from library/nginx" also carry an ID, the image tag.
+func isLayerStatus(status string) bool {
+	switch status {
+	case StatusWaiting, StatusPullingFSLayer, StatusDownloading, StatusVerifying,
+		StatusDownloadComplete, StatusExtracting, StatusPullComplete, StatusAlreadyExists:
+		return true
+	}
+	return strings.HasPrefix(status, "Retrying")
+}
+
+// Read applies the messages of a pull stream until it ends, calling
+// onMessage, if not nil, with every message and whether it changed the status
+// of a layer or of the pull. It returns the error reported by the daemon, if
+// any; a pull only completes once its stream has been consumed.
+func (t *Tracker) Read(r io.Reader, onMessage func(message Message, changed bool)) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var message Message
+		if err := decoder.Decode(&message); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if message.ErrorDetail.Message != "" {
+			return errors.New(message.ErrorDetail.Message)
+		}
+		if message.Error != "" {
+			return errors.New(message.Error)
+		}
+		changed := t.Update(message)
+		if onMessage != nil {
+			onMessage(message, changed)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2025 Bouali Consulting Inc.
+// Author: Kaiss Bouali (kaissb)
+// Company: Bouali Consulting Inc.
+// GitHub: https://github.com/kaissb
+
+package pull
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerAggregatesLayers(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"status":"Pulling from library/nginx","id":"latest"}`,
+		`{"status":"Already exists","progressDetail":{},"id":"a1"}`,
+		`{"status":"Pulling fs layer","progressDetail":{},"id":"b2"}`,
+		`{"status":"Downloading","progressDetail":{"current":50,"total":100},"progress":"[=>  ]","id":"b2"}`,
+	}, "\n")
+
+	tracker := NewTracker()
+	var changes []string
+	require.NoError(t, tracker.Read(strings.NewReader(stream), func(message Message, changed bool) {
+		if changed {
+			changes = append(changes, message.Status)
+		}
+	}))
+	assert.Equal(t, []string{"Pulling from library/nginx", StatusAlreadyExists, StatusPullingFSLayer, StatusDownloading}, changes)
+
+	progress := tracker.Progress()
+	assert.Equal(t, "Pulling from library/nginx", progress.Status)
+	assert.Equal(t, []Layer{
+		{ID: "a1", Status: StatusAlreadyExists},
+		{ID: "b2", Status: StatusDownloading, Current: 50, Total: 100},
+	}, progress.Layers)
+	// One layer done, the other halfway through its download.
+	assert.InDelta(t, (1+0.5*downloadShare)/2*100, progress.Percent, 0.001)
+
+	// Byte counts alone are not a change of status.
+	assert.False(t, tracker.Update(Message{ID: "b2", Status: StatusDownloading}))
+	assert.True(t, tracker.Update(Message{ID: "b2", Status: StatusPullComplete}))
+	assert.Equal(t, 100.0, tracker.Progress().Percent)
+}
+
+func TestReadReturnsStreamErrors(t *testing.T) {
+	stream := `{"status":"Pulling from library/missing","id":"latest"}
+{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}`
+	err := NewTracker().Read(strings.NewReader(stream), nil)
+	assert.EqualError(t, err, "manifest unknown")
+}
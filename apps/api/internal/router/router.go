@@ -6,76 +6,211 @@
 package router
 
 import (
+	"docker-manager/api/internal/audit"
+	"docker-manager/api/internal/auth"
+	"docker-manager/api/internal/config"
 	"docker-manager/api/internal/handlers"
+	"docker-manager/api/internal/models"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-// Setup initializes and configures the Gin router.
-func Setup() *gin.Engine {
-	r := gin.Default()
+// Setup initializes and configures the Gin router. Requests are logged at
+// the debug and info levels.
+func Setup(cfg *config.Config) *gin.Engine {
+	r := gin.New()
+	if cfg.LogLevel == config.LogDebug || cfg.LogLevel == config.LogInfo {
+		r.Use(gin.Logger())
+	}
+	r.Use(gin.Recovery())
 
 	// Configure CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:5173"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	r.Use(cors.New(config))
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = cfg.AllowedOrigins
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, "Authorization")
+	corsConfig.AllowCredentials = true
+	r.Use(cors.New(corsConfig))
+	handlers.AllowedOrigins = cfg.AllowedOrigins
+	handlers.AllowedBindPaths = cfg.AllowedBindPaths
 
 	r.SetTrustedProxies(nil)
 
-	// --- API Endpoints ---
+	// Record every mutating request
+	r.Use(audit.Middleware())
+
+	// --- Public Endpoints ---
 
 	// Health check
 	r.GET("/health", handlers.HealthCheck)
 
+	// Authentication
+	r.GET("/api/auth/setup", handlers.SetupStatus)
+	r.POST("/api/auth/setup", handlers.Setup)
+	r.POST("/api/auth/login", handlers.Login)
+	r.POST("/api/auth/logout", handlers.Logout)
+
+	// --- Authenticated Endpoints ---
+	protected := r.Group("", auth.Required())
+
+	// Role checks for project, environment, service and container routes.
+	project := func(role models.Role) gin.HandlerFunc { return auth.RequireRole(role, auth.ProjectScope("id")) }
+	environment := func(role models.Role) gin.HandlerFunc { return auth.RequireRole(role, auth.EnvironmentScope("id")) }
+	service := func(role models.Role) gin.HandlerFunc { return auth.RequireRole(role, auth.ServiceScope("id")) }
+	container := func(role models.Role) gin.HandlerFunc { return auth.RequireRole(role, handlers.ContainerScope("id")) }
+	job := func(role models.Role) gin.HandlerFunc { return auth.RequireRole(role, handlers.JobScope("id")) }
+
 	// Docker container endpoints
-	r.GET("/containers", handlers.ListContainers)
-	r.POST("/containers/:id/start", handlers.StartContainer)
-	r.POST("/containers/:id/stop", handlers.StopContainer)
-	r.POST("/containers/:id/restart", handlers.RestartContainer)
-	r.DELETE("/containers/:id", handlers.DeleteContainer)
+	protected.GET("/containers", handlers.ListAccessibleContainers)
+	protected.POST("/containers/:id/start", container(models.RoleDeployer), handlers.StartContainer)
+	protected.POST("/containers/:id/stop", container(models.RoleDeployer), handlers.StopContainer)
+	protected.POST("/containers/:id/restart", container(models.RoleDeployer), handlers.RestartContainer)
+	protected.DELETE("/containers/:id", container(models.RoleAdmin), handlers.DeleteContainer)
 
 	// Docker image endpoints
-	r.GET("/images", handlers.ListImages)
-	r.POST("/images/pull", handlers.PullImage)
-	r.DELETE("/images/:id", handlers.DeleteImage)
+	protected.GET("/images", handlers.ListAccessibleImages)
+	protected.POST("/images/pull", auth.AdminRequired(), handlers.PullImage)
+	protected.DELETE("/images/:id", auth.AdminRequired(), handlers.DeleteImage)
+
+	// Docker network endpoints
+	networks := protected.Group("/networks", auth.AdminRequired())
+	{
+		networks.GET("", handlers.ListNetworks)
+		networks.POST("", handlers.CreateNetwork)
+		networks.GET("/:id", handlers.GetNetwork)
+		networks.DELETE("/:id", handlers.DeleteNetwork)
+		networks.POST("/:id/connect", handlers.ConnectNetwork)
+		networks.POST("/:id/disconnect", handlers.DisconnectNetwork)
+	}
+
+	// Docker volume endpoints
+	volumes := protected.Group("/volumes", auth.AdminRequired())
+	{
+		volumes.GET("", handlers.ListVolumes)
+		volumes.POST("", handlers.CreateVolume)
+		volumes.GET("/:name", handlers.GetVolume)
+		volumes.DELETE("/:name", handlers.DeleteVolume)
+		volumes.GET("/:name/files", handlers.ListVolumeFiles)
+		volumes.GET("/:name/download", handlers.DownloadVolumeFile)
+	}
 
 	// WebSocket endpoints
-	r.GET("/ws/logs/:id", handlers.StreamLogs)
-	r.GET("/ws/terminal/:id", handlers.InteractiveTerminal)
-	r.GET("/ws/stats/:id", handlers.StreamStats)
+	protected.GET("/ws/logs/:id", container(models.RoleViewer), handlers.StreamLogs)
+	protected.GET("/ws/terminal/:id", container(models.RoleDeployer), handlers.InteractiveTerminal)
+	protected.GET("/ws/stats/:id", container(models.RoleViewer), handlers.StreamStats)
+	protected.GET("/ws/jobs/:id", job(models.RoleViewer), handlers.StreamJob)
+	protected.GET("/ws/events", handlers.StreamEvents)
 
 	// Project endpoints
-	api := r.Group("/api")
+	api := protected.Group("/api")
 	{
+		api.GET("/auth/me", handlers.GetCurrentUser)
+		api.POST("/auth/password", auth.SessionRequired(), handlers.ChangePassword)
+
+		keys := api.Group("/keys", auth.SessionRequired())
+		{
+			keys.POST("", handlers.CreateAPIKey)
+			keys.GET("", handlers.ListAPIKeys)
+			keys.DELETE("/:id", handlers.RevokeAPIKey)
+		}
+
+		users := api.Group("/users", auth.AdminRequired())
+		{
+			users.GET("", handlers.ListUsers)
+			users.POST("", handlers.CreateUser)
+			users.DELETE("/:id", handlers.DeleteUser)
+		}
+
 		projects := api.Group("/projects")
 		{
-			projects.POST("", handlers.CreateProject)
+			projects.POST("", auth.KeyScopeRequired(models.RoleAdmin), handlers.CreateProject)
 			projects.GET("", handlers.ListProjects)
-			projects.GET("/:id", handlers.GetProject)
-			projects.POST("/:id/environments", handlers.CreateEnvironment)
-			projects.GET("/:id/environments", handlers.ListEnvironments)
+			projects.GET("/:id", project(models.RoleViewer), handlers.GetProject)
+			projects.PUT("/:id", project(models.RoleAdmin), handlers.UpdateProject)
+			projects.DELETE("/:id", project(models.RoleAdmin), handlers.DeleteProject)
+			projects.POST("/:id/environments", project(models.RoleAdmin), handlers.CreateEnvironment)
+			projects.GET("/:id/environments", project(models.RoleViewer), handlers.ListEnvironments)
+
+			// Members
+			projects.GET("/:id/members", project(models.RoleViewer), handlers.ListProjectMembers)
+			projects.POST("/:id/members", project(models.RoleAdmin), handlers.SetProjectMember)
+			projects.DELETE("/:id/members/:userId", project(models.RoleAdmin), handlers.RemoveProjectMember)
+
+			// Registry credentials
+			projects.GET("/:id/registries", project(models.RoleDeveloper), handlers.ListRegistryCredentials)
+			projects.POST("/:id/registries", project(models.RoleAdmin), handlers.CreateRegistryCredential)
+			projects.PUT("/:id/registries/:credentialId", project(models.RoleAdmin), handlers.UpdateRegistryCredential)
+			projects.DELETE("/:id/registries/:credentialId", project(models.RoleAdmin), handlers.DeleteRegistryCredential)
+			projects.POST("/:id/registries/:credentialId/test", project(models.RoleAdmin), handlers.TestRegistryCredential)
 		}
 
 		environments := api.Group("/environments")
 		{
-			environments.POST("/:id/services", handlers.CreateService)
-			environments.GET("/:id/services", handlers.ListServices)
+			// Project admins manage environments, environment overrides do not apply
+			environmentAdmin := auth.RequireRole(models.RoleAdmin, auth.ProjectOnly(auth.EnvironmentScope("id")))
+			environments.PUT("/:id", environmentAdmin, handlers.UpdateEnvironment)
+			environments.DELETE("/:id", environmentAdmin, handlers.DeleteEnvironment)
+			environments.POST("/:id/services", environment(models.RoleDeveloper), handlers.CreateService)
+			environments.GET("/:id/services", environment(models.RoleViewer), handlers.ListServices)
+			environments.POST("/:id/adopt", auth.AdminRequired(), handlers.AdoptContainers)
 
 			// Environment Variables
-			environments.POST("/:id/variables", handlers.CreateEnvironmentVariable)
-			environments.GET("/:id/variables", handlers.ListEnvironmentVariables)
-			environments.PUT("/:id/variables/:varId", handlers.UpdateEnvironmentVariable)
-			environments.DELETE("/:id/variables/:varId", handlers.DeleteEnvironmentVariable)
+			environments.POST("/:id/variables", environment(models.RoleDeveloper), handlers.CreateEnvironmentVariable)
+			environments.GET("/:id/variables", environment(models.RoleDeveloper), handlers.ListEnvironmentVariables)
+			environments.PUT("/:id/variables/:varId", environment(models.RoleDeveloper), handlers.UpdateEnvironmentVariable)
+			environments.DELETE("/:id/variables/:varId", environment(models.RoleDeveloper), handlers.DeleteEnvironmentVariable)
+
+			// Role overrides, managed by project admins
+			environments.GET("/:id/members", environment(models.RoleViewer), handlers.ListEnvironmentMembers)
+			environments.POST("/:id/members", environmentAdmin, handlers.SetEnvironmentMember)
+			environments.DELETE("/:id/members/:userId", environmentAdmin, handlers.RemoveEnvironmentMember)
 		}
 
 		services := api.Group("/services")
 		{
-			services.GET("/:id", handlers.GetServiceDetails)
-			services.POST("/:id/up", handlers.UpService)
-			services.POST("/:id/down", handlers.DownService)
-			services.POST("/:id/scale", handlers.ScaleService)
+			services.GET("/:id", service(models.RoleViewer), handlers.GetServiceDetails)
+			services.PUT("/:id", service(models.RoleDeveloper), handlers.UpdateService)
+			services.DELETE("/:id", service(models.RoleDeployer), handlers.DeleteService)
+			services.POST("/:id/up", service(models.RoleDeployer), handlers.UpService)
+			services.POST("/:id/down", service(models.RoleDeployer), handlers.DownService)
+			services.POST("/:id/scale", service(models.RoleDeployer), handlers.ScaleService)
+			services.GET("/:id/deployments", service(models.RoleViewer), handlers.ListDeployments)
+			services.GET("/:id/jobs", service(models.RoleViewer), handlers.ListServiceJobs)
+			services.GET("/:id/volumes", service(models.RoleViewer), handlers.ListServiceVolumes)
+			services.POST("/:id/rollback/:deploymentId", service(models.RoleDeployer), handlers.RollbackService)
+		}
+
+		jobs := api.Group("/jobs")
+		{
+			jobs.GET("/:id", job(models.RoleViewer), handlers.GetJob)
+			jobs.POST("/:id/cancel", job(models.RoleDeployer), handlers.CancelJob)
+		}
+
+		// Install-wide registry credentials
+		registries := api.Group("/registries", auth.AdminRequired())
+		{
+			registries.GET("", handlers.ListRegistryCredentials)
+			registries.POST("", handlers.CreateRegistryCredential)
+			registries.PUT("/:credentialId", handlers.UpdateRegistryCredential)
+			registries.DELETE("/:credentialId", handlers.DeleteRegistryCredential)
+			registries.POST("/:credentialId/test", handlers.TestRegistryCredential)
+		}
+
+		api.GET("/audit", auth.AdminRequired(), handlers.ListAuditEvents)
+
+		// Containers started outside of DockMan
+		api.GET("/adoptable", auth.AdminRequired(), handlers.ListAdoptableContainers)
+
+		// Differences between the database and the Docker host
+		api.GET("/drift", auth.AdminRequired(), handlers.GetDrift)
+		api.POST("/drift/heal", auth.AdminRequired(), handlers.HealDrift)
+
+		admin := api.Group("/admin", auth.AdminRequired())
+		{
+			admin.GET("/encryption/keys", handlers.ListEncryptionKeys)
+			admin.POST("/encryption/rotate", handlers.RotateEncryptionKey)
+			admin.POST("/backup", handlers.CreateBackup)
 		}
 	}
 